@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/pepper"
+	"github.com/jrpalma/pwdhash/task"
+)
+
+const usage = `Usage:
+  pwdhash [flags]                 Runs the password hash service.
+  pwdhash [flags] pepper rotate   Adds a new current key to the pepper key ring.
+  pwdhash [flags] calibrate [-target 250ms] [-write]
+                                  Proposes hash parameters for the target latency.
+  pwdhash [flags] config check    Reports every problem in the configuration.
+
+Flags:
+  --config file  The configuration file. The default is config.json.
+  --no-write     Never writes the configuration file.
+  --<name>       Sets a configuration value. Run pwdhash -h for the names.`
+
+// runCommand Runs the command given in the command line arguments. The
+// configuration is validated first except by the config command.
+func runCommand(conf config.Config, sources config.Sources, args []string) error {
+	if args[0] == "config" {
+		return configCommand(conf, args[1:])
+	}
+
+	err := task.ValidateConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "pepper":
+		return pepperCommand(conf, args[1:])
+	case "calibrate":
+		return calibrateCommand(conf, sources, args[1:])
+	}
+	return fmt.Errorf("Unknown command %v\n%v", args[0], usage)
+}
+
+func configCommand(conf config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return fmt.Errorf("Invalid config command\n%v", usage)
+	}
+
+	err := task.ValidateConfig(conf)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("The configuration is valid")
+	return nil
+}
+
+func pepperCommand(conf config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "rotate" {
+		return fmt.Errorf("Invalid pepper command\n%v", usage)
+	}
+	if conf.PepperFile == "" {
+		return fmt.Errorf("PepperFile is not configured")
+	}
+
+	version, err := pepper.RotateFile(conf.PepperFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pepper key version %v is now the current key\n", version)
+	return nil
+}
+
+func calibrateCommand(conf config.Config, sources config.Sources, args []string) error {
+	flags := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	target := flags.Duration("target", 250*time.Millisecond, "The target duration of a single hash.")
+	write := flags.Bool("write", false, "Writes the proposed parameters to the configuration file.")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	calibrated, calibrations, err := task.Calibrate(conf, *target)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ALGORITHM\tPARAMETERS\tDURATION")
+	for _, calibration := range calibrations {
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", calibration.Algorithm,
+			calibration.Parameters, calibration.Duration.Round(time.Millisecond))
+	}
+	writer.Flush()
+
+	if !*write {
+		return nil
+	}
+	if sources.NoWrite {
+		return fmt.Errorf("The configuration file cannot be written with --no-write")
+	}
+
+	err = config.UpdateFile(sources.File, func(file *config.Config) {
+		file.Argon2 = calibrated.Argon2
+		file.Bcrypt = calibrated.Bcrypt
+		file.Scrypt = calibrated.Scrypt
+		file.PBKDF2 = calibrated.PBKDF2
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Parameters saved to %v\n", sources.File)
+	return nil
+}
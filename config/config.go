@@ -24,23 +24,164 @@ type Config struct {
 	// CheckPasswordStrength Flag used to enable the password strength checks.
 	CheckPasswordStrength bool
 
-	// PasswordStrength Rules used to check the password strength.
+	// PasswordStrength Rules used to check the password strength. These are
+	// the rules of the policy named default unless PasswordPolicies has one.
 	PasswordStrength strength.PasswordStrength
 
+	// PasswordPolicies The password strength rules by policy name. For
+	// example: different rules for admin accounts and end users.
+	PasswordPolicies map[string]strength.PasswordStrength
+
+	// DefaultPolicy The name of the policy used when a request does not
+	// select one. The policy named default is used if this is empty.
+	DefaultPolicy string
+
+	// BreachFile The path to a file of breached password hashes. The file has
+	// sorted SHA-1 or NTLM "HASH:count" lines like the Have I Been Pwned
+	// downloads. The breached passwords are allowed if this is empty.
+	BreachFile string
+
+	// BreachThreshold The number of breaches a password can appear in
+	// before it is rejected. Every breached password is rejected if this
+	// is zero.
+	BreachThreshold uint
+
+	// HashAlgorithm The name of the algorithm used to hash passwords when a request
+	// does not ask for one. Valid values are: sha512, bcrypt, scrypt, pbkdf2-sha256,
+	// and argon2id. The unsalted sha512 algorithm is used if this is empty.
+	HashAlgorithm string
+
+	// Argon2 The parameters used by the argon2id hash algorithm.
+	Argon2 Argon2
+
+	// Bcrypt The parameters used by the bcrypt hash algorithm.
+	Bcrypt Bcrypt
+
+	// Scrypt The parameters used by the scrypt hash algorithm.
+	Scrypt Scrypt
+
+	// PBKDF2 The parameters used by the pbkdf2-sha256 hash algorithm.
+	PBKDF2 PBKDF2
+
+	// PepperFile The path to the pepper key ring file. If set, passwords are
+	// peppered with the current key before they are hashed. Peppering is
+	// disabled if this is empty.
+	PepperFile string
+
 	// MaxTaskSeconds The maximum number of seconds the hash task will take.
 	MaxTaskSeconds uint
 
+	// TaskRetentionSeconds The number of seconds a completed task is kept.
+	// Completed tasks are kept until they are evicted if this is zero.
+	TaskRetentionSeconds uint
+
+	// MaxCompletedTasks The maximum number of completed tasks kept. The least
+	// recently used tasks are evicted first. There is no limit if this is zero.
+	MaxCompletedTasks uint
+
+	// IDScheme The scheme of the hash IDs. Valid values are random, uuidv7,
+	// ulid, and sequential. Sequential IDs can be enumerated so any client
+	// can check the hashes of other clients. Sequential IDs are used if
+	// this is empty.
+	IDScheme string
+
+	// DataDirectory The directory where the tasks are stored so they survive
+	// restarts. The tasks are only kept in memory if this is empty.
+	DataDirectory string
+
+	// DataKeyFile The path to the key that encrypts the passwords of the
+	// pending tasks stored in DataDirectory. The file is created with a
+	// random key if it does not exist and must not be in DataDirectory.
+	// If this is empty, the passwords are never stored and the pending
+	// tasks fail after a restart.
+	DataKeyFile string
+
+	// IdempotencyWindowSeconds The number of seconds a repeated Idempotency-Key
+	// returns the task created by the first request. The Idempotency-Key
+	// header is ignored if this is zero.
+	IdempotencyWindowSeconds uint
+
+	// Workers The number of workers that run the hash tasks.
+	Workers uint
+
+	// QueueSize The maximum number of tasks waiting for a worker. New tasks
+	// are rejected with status 503 when the queue is full.
+	QueueSize uint
+
 	// ServerAddress The server address to listen on. For example: ":80"
 	ServerAddress string
 }
 
-// OpenFile Opens or creates a configuration file. If the file exist,
-// the file is opened and loaded. If the file does not exis, the file
-// is created with the default values and saved. The default values
-// used are: Log level WARN, Log Destination STDERR, and checks for
-// password strength. The default password strength has a mininum
-// length of 8 plus a minimum 1 upper case, 1 lower case, 1 digit,
-// and 1 special character. The default runtime is 5 seconds.
+// Argon2 Represents the argon2id hash algorithm parameters. A zero
+// value means that the recommended value is used.
+type Argon2 struct {
+	// Memory The memory cost in KiB. For example: 19456 (19 MiB).
+	Memory uint32
+	// Iterations The number of passes over the memory.
+	Iterations uint32
+	// Parallelism The number of threads used to compute the hash.
+	Parallelism uint8
+	// SaltLength The length in bytes of the random salt created for each hash.
+	SaltLength uint32
+	// KeyLength The length in bytes of the resulting hash.
+	KeyLength uint32
+}
+
+// Bcrypt Represents the bcrypt hash algorithm parameters. A zero
+// value means that the recommended value is used.
+type Bcrypt struct {
+	// Cost The base-2 logarithm of the number of rounds. For example: 10.
+	Cost int
+}
+
+// Scrypt Represents the scrypt hash algorithm parameters. A zero
+// value means that the recommended value is used.
+type Scrypt struct {
+	// LogN The base-2 logarithm of the CPU and memory cost. For example: 15.
+	LogN uint
+	// BlockSize The block size parameter r.
+	BlockSize int
+	// Parallelism The parallelization parameter p.
+	Parallelism int
+}
+
+// PBKDF2 Represents the pbkdf2-sha256 hash algorithm parameters. A zero
+// value means that the recommended value is used.
+type PBKDF2 struct {
+	// Iterations The number of HMAC-SHA256 iterations.
+	Iterations int
+}
+
+// Policy Returns the password policy with the given name and the name of
+// the policy found. The default policy is returned if name is empty. This
+// function returns false if there is no policy with that name.
+func (c *Config) Policy(name string) (strength.PasswordStrength, string, bool) {
+	if name == "" {
+		name = c.DefaultPolicy
+	}
+	if name == "" {
+		name = DefaultPolicyName
+	}
+
+	rules, exists := c.PasswordPolicies[name]
+	if exists {
+		return rules, name, true
+	}
+
+	if name == DefaultPolicyName {
+		return c.PasswordStrength, name, true
+	}
+
+	return strength.PasswordStrength{}, name, false
+}
+
+// DefaultPolicyName The name of the policy with the PasswordStrength rules.
+const DefaultPolicyName = "default"
+
+// OpenFile Opens or creates a configuration file. If the file exists,
+// the file is opened and loaded. If the file does not exist, the file is
+// created with the default values of setDefaults and setStrengthDefaults
+// and saved.
 func (c *Config) OpenFile(filePath string) error {
 
 	content, err := ioutil.ReadFile(filePath)
@@ -49,30 +190,8 @@ func (c *Config) OpenFile(filePath string) error {
 	}
 
 	if os.IsNotExist(err) {
-		if c.LogLevel == "" {
-			c.LogLevel = logs.WARN
-		}
-		if c.LogDestination == "" {
-			c.LogDestination = logs.STDERR
-		}
-		if c.MaxTaskSeconds == 0 {
-			c.MaxTaskSeconds = 5
-		}
-		if c.ServerAddress == "" {
-			c.ServerAddress = ":8080"
-		}
-		if c.PasswordStrength.MaxLength == 0 {
-			c.PasswordStrength.MaxLength = 50
-		}
-
-		c.CheckPasswordStrength = true
-		c.PasswordStrength.MinDigits = 1
-		c.PasswordStrength.MinUpperCase = 1
-		c.PasswordStrength.MinLowerCase = 1
-		c.PasswordStrength.MinDigits = 1
-		c.PasswordStrength.MinSpecial = 1
-		c.PasswordStrength.MinLength = 8
-
+		c.setDefaults()
+		c.setStrengthDefaults()
 		return c.SaveFile(filePath)
 	}
 
@@ -84,6 +203,110 @@ func (c *Config) OpenFile(filePath string) error {
 	return nil
 }
 
+// setDefaults Sets the default values used by OpenFile and Load. Only the
+// zero values are replaced. The default values used are: Log level WARN and
+// Log Destination STDERR. The default runtime is 5 seconds and the default
+// hash algorithm is sha512. The default argon2id parameters are 19 MiB of
+// memory, 2 iterations, 1 thread, a 16 byte salt, and a 32 byte key. The
+// default bcrypt cost is 10, the default scrypt parameters are N=2^15, r=8,
+// and p=1, and the default pbkdf2-sha256 iterations are 600000. The default
+// worker pool has 64 workers and a queue of 10000 tasks. Completed tasks are
+// kept for 1 hour and there are at most 100000 completed tasks. Hash IDs are
+// random and idempotency keys are remembered for 24 hours. The server
+// listens on :8080.
+func (c *Config) setDefaults() {
+	if c.LogLevel == "" {
+		c.LogLevel = logs.WARN
+	}
+	if c.LogDestination == "" {
+		c.LogDestination = logs.STDERR
+	}
+	if c.MaxTaskSeconds == 0 {
+		c.MaxTaskSeconds = 5
+	}
+	if c.TaskRetentionSeconds == 0 {
+		c.TaskRetentionSeconds = 3600
+	}
+	if c.MaxCompletedTasks == 0 {
+		c.MaxCompletedTasks = 100000
+	}
+	if c.IDScheme == "" {
+		c.IDScheme = "random"
+	}
+	if c.IdempotencyWindowSeconds == 0 {
+		c.IdempotencyWindowSeconds = 86400
+	}
+	if c.Workers == 0 {
+		c.Workers = 64
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 10000
+	}
+	if c.ServerAddress == "" {
+		c.ServerAddress = ":8080"
+	}
+	if c.HashAlgorithm == "" {
+		c.HashAlgorithm = "sha512"
+	}
+	if c.Argon2.Memory == 0 {
+		c.Argon2.Memory = 19 * 1024
+	}
+	if c.Argon2.Iterations == 0 {
+		c.Argon2.Iterations = 2
+	}
+	if c.Argon2.Parallelism == 0 {
+		c.Argon2.Parallelism = 1
+	}
+	if c.Argon2.SaltLength == 0 {
+		c.Argon2.SaltLength = 16
+	}
+	if c.Argon2.KeyLength == 0 {
+		c.Argon2.KeyLength = 32
+	}
+	if c.Bcrypt.Cost == 0 {
+		c.Bcrypt.Cost = 10
+	}
+	if c.Scrypt.LogN == 0 {
+		c.Scrypt.LogN = 15
+	}
+	if c.Scrypt.BlockSize == 0 {
+		c.Scrypt.BlockSize = 8
+	}
+	if c.Scrypt.Parallelism == 0 {
+		c.Scrypt.Parallelism = 1
+	}
+	if c.PBKDF2.Iterations == 0 {
+		c.PBKDF2.Iterations = 600000
+	}
+}
+
+// setStrengthDefaults Sets the default password strength rules. These are
+// only used when the configuration file is created since a zero rule is
+// a valid choice, so an existing file keeps the rules it names. The
+// default values used are: checks for password strength, a minimum length
+// of 8, a maximum length of 50, a minimum of 1 upper case, 1 lower case,
+// 1 digit, and 1 special character, and a minimum entropy score of 3. Any
+// punctuation or symbol is a special character and control characters are
+// rejected. Passwords within an edit distance of 2 of the user context are
+// rejected.
+func (c *Config) setStrengthDefaults() {
+	if c.PasswordStrength.MaxLength == 0 {
+		c.PasswordStrength.MaxLength = 50
+	}
+
+	c.CheckPasswordStrength = true
+	c.PasswordStrength.MinDigits = 1
+	c.PasswordStrength.MinUpperCase = 1
+	c.PasswordStrength.MinLowerCase = 1
+	c.PasswordStrength.MinDigits = 1
+	c.PasswordStrength.MinSpecial = 1
+	c.PasswordStrength.MinLength = 8
+	c.PasswordStrength.MinScore = 3
+	c.PasswordStrength.ContextDistance = 2
+	c.PasswordStrength.SpecialCategories = []string{"P", "S"}
+	c.PasswordStrength.ForbidControl = true
+}
+
 // SaveFile Saves the JSON configuration to the filePath.
 func (c *Config) SaveFile(filePath string) error {
 	bytes, err := json.Marshal(c)
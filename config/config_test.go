@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/jrpalma/pwdhash/logs"
+	"github.com/jrpalma/pwdhash/strength"
 )
 
 func defaultConfig() *Config {
@@ -22,7 +23,28 @@ func defaultConfig() *Config {
 	conf.PasswordStrength.MinSpecial = 1
 	conf.PasswordStrength.MinLength = 8
 	conf.PasswordStrength.MaxLength = 50
+	conf.PasswordStrength.MinScore = 3
+	conf.PasswordStrength.ContextDistance = 2
+	conf.PasswordStrength.SpecialCategories = []string{"P", "S"}
+	conf.PasswordStrength.ForbidControl = true
+	conf.HashAlgorithm = "sha512"
+	conf.Argon2.Memory = 19 * 1024
+	conf.Argon2.Iterations = 2
+	conf.Argon2.Parallelism = 1
+	conf.Argon2.SaltLength = 16
+	conf.Argon2.KeyLength = 32
+	conf.Bcrypt.Cost = 10
+	conf.Scrypt.LogN = 15
+	conf.Scrypt.BlockSize = 8
+	conf.Scrypt.Parallelism = 1
+	conf.PBKDF2.Iterations = 600000
 	conf.MaxTaskSeconds = 5
+	conf.TaskRetentionSeconds = 3600
+	conf.MaxCompletedTasks = 100000
+	conf.IDScheme = "random"
+	conf.IdempotencyWindowSeconds = 86400
+	conf.Workers = 64
+	conf.QueueSize = 10000
 	conf.ServerAddress = ":8080"
 	return conf
 }
@@ -150,3 +172,39 @@ func TestCreateLogger_Success(t *testing.T) {
 		t.Errorf("CreateLogger failed: %v", err)
 	}
 }
+
+func TestConfig_Policy(t *testing.T) {
+	conf := Config{}
+	conf.PasswordStrength.MinLength = 8
+	conf.PasswordPolicies = map[string]strength.PasswordStrength{
+		"admin":   {MinLength: 16},
+		"service": {MinLength: 32},
+	}
+
+	rules, name, exists := conf.Policy("")
+	if !exists || name != DefaultPolicyName || rules.MinLength != 8 {
+		t.Errorf("Policy should return the PasswordStrength rules, not %v %+v", name, rules)
+	}
+
+	rules, name, exists = conf.Policy("admin")
+	if !exists || name != "admin" || rules.MinLength != 16 {
+		t.Errorf("Policy should return the admin rules, not %v %+v", name, rules)
+	}
+
+	conf.DefaultPolicy = "service"
+	rules, name, exists = conf.Policy("")
+	if !exists || name != "service" || rules.MinLength != 32 {
+		t.Errorf("Policy should return the default policy, not %v %+v", name, rules)
+	}
+
+	_, _, exists = conf.Policy("unknown")
+	if exists {
+		t.Errorf("Policy should not return an unknown policy")
+	}
+
+	conf.PasswordPolicies[DefaultPolicyName] = strength.PasswordStrength{MinLength: 12}
+	rules, _, _ = conf.Policy(DefaultPolicyName)
+	if rules.MinLength != 12 {
+		t.Errorf("Policy should prefer the default policy in PasswordPolicies, not %+v", rules)
+	}
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Sources Describes where a layered configuration was loaded from.
+type Sources struct {
+	// File The configuration file. The file might not exist.
+	File string
+	// NoWrite True if the configuration file must never be written.
+	NoWrite bool
+	// Args The command line arguments left after the flags.
+	Args []string
+}
+
+// Load Loads the configuration in layers. The built-in defaults come first,
+// then the configuration file, then the PWDHASH_* environment variables, and
+// then the command line flags. Each layer only changes the values it sets.
+// The file is set by the --config flag or PWDHASH_CONFIG and it is
+// config.json by default. A missing file is created with the defaults unless
+// --no-write or PWDHASH_NO_WRITE is set. The default password strength rules
+// only apply when the file is missing so an existing file keeps its rules.
+// The environment and flag values are never written to the file.
+//
+// Every value has an environment variable and a flag named after its path.
+// For example: PasswordStrength.MinLength is PWDHASH_PASSWORD_STRENGTH_MIN_LENGTH
+// and --password-strength-min-length. Lists are separated by commas. The
+// password policies can only be set in the file.
+func Load(args []string, environ []string) (Config, Sources, error) {
+	conf := Config{}
+	conf.setDefaults()
+
+	sources := Sources{File: defaultFile}
+	env := make(map[string]string)
+	for _, entry := range environ {
+		pair := strings.SplitN(entry, "=", 2)
+		if len(pair) == 2 && strings.HasPrefix(pair[0], envPrefix) {
+			env[pair[0]] = pair[1]
+		}
+	}
+
+	if file, exists := env[envPrefix+"CONFIG"]; exists {
+		sources.File = file
+	}
+	if noWrite, exists := env[envPrefix+"NO_WRITE"]; exists {
+		value, err := strconv.ParseBool(noWrite)
+		if err != nil {
+			return conf, sources, fmt.Errorf("config: Invalid %vNO_WRITE: %v", envPrefix, err)
+		}
+		sources.NoWrite = value
+	}
+
+	fields := configFields(reflect.ValueOf(&conf).Elem(), "", "")
+
+	flags := flag.NewFlagSet("pwdhash", flag.ContinueOnError)
+	flags.StringVar(&sources.File, "config", sources.File, "The configuration file.")
+	flags.BoolVar(&sources.NoWrite, "no-write", sources.NoWrite, "Never writes the configuration file.")
+
+	var flagValues []fieldValue
+	for _, field := range fields {
+		flags.Var(&fieldFlag{field: field, values: &flagValues}, field.flag,
+			fmt.Sprintf("Sets %v.", field.path))
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return conf, sources, err
+	}
+	sources.Args = flags.Args()
+
+	err = conf.loadFile(sources)
+	if err != nil {
+		return conf, sources, err
+	}
+
+	for _, field := range fields {
+		value, exists := env[field.env]
+		if !exists {
+			continue
+		}
+		err = field.set(value)
+		if err != nil {
+			return conf, sources, fmt.Errorf("config: Invalid %v: %v", field.env, err)
+		}
+	}
+
+	for _, flagValue := range flagValues {
+		err = flagValue.field.set(flagValue.value)
+		if err != nil {
+			return conf, sources, fmt.Errorf("config: Invalid --%v: %v", flagValue.field.flag, err)
+		}
+	}
+
+	return conf, sources, nil
+}
+
+// loadFile Loads the configuration file on top of the defaults. A missing
+// file is created with the defaults unless the file must not be written.
+// The default password strength rules are only used if the file is missing.
+func (c *Config) loadFile(sources Sources) error {
+	content, err := ioutil.ReadFile(sources.File)
+	if os.IsNotExist(err) {
+		c.setStrengthDefaults()
+		if sources.NoWrite {
+			return nil
+		}
+		return c.SaveFile(sources.File)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, c)
+}
+
+// UpdateFile Changes the configuration file without the environment
+// variables and flags. The file is loaded on top of the defaults, changed
+// by update, and saved.
+func UpdateFile(filePath string, update func(conf *Config)) error {
+	conf := Config{}
+	conf.setDefaults()
+
+	err := conf.loadFile(Sources{File: filePath, NoWrite: true})
+	if err != nil {
+		return err
+	}
+
+	update(&conf)
+	return conf.SaveFile(filePath)
+}
+
+// field A configuration value that can be set from text.
+type field struct {
+	path  string
+	env   string
+	flag  string
+	value reflect.Value
+}
+
+// configFields Returns the fields of the struct and its nested structs.
+// The fields that cannot be set from text are skipped.
+func configFields(v reflect.Value, path, name string) []field {
+	var fields []field
+
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		fieldPath := path + structField.Name
+		fieldName := name + snakeCase(structField.Name)
+		value := v.Field(i)
+
+		switch value.Kind() {
+		case reflect.Struct:
+			fields = append(fields, configFields(value, fieldPath+".", fieldName+"_")...)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Uint,
+			reflect.Uint8, reflect.Uint32, reflect.Uint64:
+			fields = append(fields, newField(fieldPath, fieldName, value))
+		case reflect.Slice:
+			if value.Type().Elem().Kind() == reflect.String {
+				fields = append(fields, newField(fieldPath, fieldName, value))
+			}
+		}
+	}
+
+	return fields
+}
+
+func newField(path, name string, value reflect.Value) field {
+	return field{
+		path:  path,
+		env:   envPrefix + name,
+		flag:  strings.ReplaceAll(strings.ToLower(name), "_", "-"),
+		value: value,
+	}
+}
+
+// set Parses the text and sets the field.
+func (f field) set(text string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(text)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(value)
+	case reflect.Int:
+		value, err := strconv.ParseInt(text, 10, f.value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(text, 10, f.value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.value.SetUint(value)
+	case reflect.Slice:
+		var values []string
+		for _, value := range strings.Split(text, ",") {
+			value = strings.TrimSpace(value)
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		f.value.Set(reflect.ValueOf(values))
+	}
+	return nil
+}
+
+// fieldValue A field and the text given on the command line.
+type fieldValue struct {
+	field field
+	value string
+}
+
+// fieldFlag Records the flag values so they are only set after the file
+// and the environment variables are loaded.
+type fieldFlag struct {
+	field  field
+	values *[]fieldValue
+}
+
+func (f *fieldFlag) String() string {
+	return ""
+}
+
+func (f *fieldFlag) Set(value string) error {
+	*f.values = append(*f.values, fieldValue{f.field, value})
+	return nil
+}
+
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.field.value.Kind() == reflect.Bool
+}
+
+// snakeCase Returns the upper case snake case of a Go name. For example:
+// IDScheme is ID_SCHEME and PBKDF2 is PBKDF2.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) && nextLower ||
+				unicode.IsUpper(previous) && nextLower {
+				builder.WriteByte('_')
+			}
+		}
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+
+	return builder.String()
+}
+
+const (
+	defaultFile = "config.json"
+	envPrefix   = "PWDHASH_"
+)
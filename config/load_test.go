@@ -0,0 +1,190 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/logs"
+)
+
+func TestLoad_Layers(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"LogLevel":"INFO","Workers":8,"QueueSize":100,"ServerAddress":":9090"}`
+	ioutil.WriteFile(file, []byte(content), 0644)
+
+	environ := []string{
+		"PWDHASH_QUEUE_SIZE=200",
+		"PWDHASH_SERVER_ADDRESS=:7070",
+		"PWDHASH_PASSWORD_STRENGTH_SPECIAL_CATEGORIES=P, Sm",
+		"HOME=/root",
+	}
+	args := []string{"--config", file, "--server-address", ":6060", "--check-password-strength=false", "calibrate", "-write"}
+
+	conf, sources, err := Load(args, environ)
+	if err != nil {
+		t.Errorf("Load failed: %v", err)
+		return
+	}
+
+	// The defaults are kept if no layer sets them
+	if conf.MaxTaskSeconds != 5 || conf.PBKDF2.Iterations != 600000 {
+		t.Errorf("Load should keep the defaults: %+v", conf)
+	}
+	if conf.LogLevel != logs.INFO || conf.Workers != 8 {
+		t.Errorf("Load should load the file: %+v", conf)
+	}
+	if conf.QueueSize != 200 || !reflect.DeepEqual(conf.PasswordStrength.SpecialCategories, []string{"P", "Sm"}) {
+		t.Errorf("Load should load the environment: %+v", conf)
+	}
+	if conf.ServerAddress != ":6060" || conf.CheckPasswordStrength {
+		t.Errorf("Load should load the flags last: %+v", conf)
+	}
+
+	if sources.File != file || !reflect.DeepEqual(sources.Args, []string{"calibrate", "-write"}) {
+		t.Errorf("Load returned the sources: %+v", sources)
+	}
+}
+
+func TestLoad_ExistingFileRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"CheckPasswordStrength":true,"PasswordStrength":{"MinLength":8,"MinDigits":1}}`
+	ioutil.WriteFile(file, []byte(content), 0644)
+
+	conf, _, err := Load([]string{"--config", file}, nil)
+	if err != nil {
+		t.Errorf("Load failed: %v", err)
+		return
+	}
+
+	// The rules the file does not name are not set by the defaults
+	rules := conf.PasswordStrength
+	if rules.MinLength != 8 || rules.MinDigits != 1 || rules.MinUpperCase != 0 || rules.MinScore != 0 ||
+		rules.ContextDistance != 0 || rules.ForbidControl || rules.SpecialCategories != nil {
+		t.Errorf("Load should keep the rules of the file: %+v", rules)
+	}
+	if conf.Workers != 64 {
+		t.Errorf("Load should still use the other defaults: %+v", conf)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+
+	conf, _, err := Load([]string{"--workers", "4"}, []string{"PWDHASH_CONFIG=" + file})
+	if err != nil {
+		t.Errorf("Load failed: %v", err)
+		return
+	}
+
+	if conf.Workers != 4 {
+		t.Errorf("Load should return 4 workers, not %v", conf.Workers)
+	}
+
+	saved := Config{}
+	err = saved.OpenFile(file)
+	if err != nil || !reflect.DeepEqual(&saved, defaultConfig()) {
+		t.Errorf("Load should only save the defaults: %+v", saved)
+	}
+}
+
+func TestLoad_NoWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+
+	conf, sources, err := Load([]string{"--config", file, "--no-write"}, nil)
+	if err != nil {
+		t.Errorf("Load failed: %v", err)
+		return
+	}
+
+	if !sources.NoWrite || !reflect.DeepEqual(&conf, defaultConfig()) {
+		t.Errorf("Load should return the defaults: %+v", conf)
+	}
+
+	_, err = os.Stat(file)
+	if !os.IsNotExist(err) {
+		t.Errorf("Load should not write the file: %v", err)
+	}
+
+	_, sources, err = Load([]string{"--config", file}, []string{"PWDHASH_NO_WRITE=true"})
+	if err != nil || !sources.NoWrite {
+		t.Errorf("Load should not write with PWDHASH_NO_WRITE: %v", err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+
+	_, _, err := Load([]string{"--config", file, "--no-write", "--workers", "many"}, nil)
+	if err == nil {
+		t.Errorf("Load should fail with an invalid flag value")
+	}
+
+	_, _, err = Load([]string{"--config", file, "--no-write"}, []string{"PWDHASH_BCRYPT_COST=high"})
+	if err == nil {
+		t.Errorf("Load should fail with an invalid environment variable")
+	}
+
+	_, _, err = Load([]string{"--unknown"}, nil)
+	if err == nil {
+		t.Errorf("Load should fail with an unknown flag")
+	}
+}
+
+func TestUpdateFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"LogLevel":"INFO","Workers":8}`
+	ioutil.WriteFile(file, []byte(content), 0644)
+
+	environ := []string{"PWDHASH_LOG_LEVEL=DEBUG", "PWDHASH_BCRYPT_COST=4"}
+	conf, _, err := Load([]string{"--config", file, "--queue-size", "7"}, environ)
+	if err != nil {
+		t.Errorf("Load failed: %v", err)
+		return
+	}
+
+	conf.Bcrypt.Cost = 12
+	err = UpdateFile(file, func(saved *Config) {
+		saved.Bcrypt = conf.Bcrypt
+	})
+	if err != nil {
+		t.Errorf("UpdateFile failed: %v", err)
+		return
+	}
+
+	saved := Config{}
+	err = saved.OpenFile(file)
+	if err != nil {
+		t.Errorf("OpenFile failed: %v", err)
+		return
+	}
+
+	if saved.Bcrypt.Cost != 12 {
+		t.Errorf("UpdateFile should save a cost of 12, not %v", saved.Bcrypt.Cost)
+	}
+	if saved.LogLevel != logs.INFO || saved.Workers != 8 {
+		t.Errorf("UpdateFile should keep the file values: %+v", saved)
+	}
+	if saved.QueueSize != defaultConfig().QueueSize {
+		t.Errorf("UpdateFile should not save the flags: %+v", saved)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"LogLevel":                 "LOG_LEVEL",
+		"IDScheme":                 "ID_SCHEME",
+		"PBKDF2":                   "PBKDF2",
+		"Argon2":                   "ARGON2",
+		"LogN":                     "LOG_N",
+		"IdempotencyWindowSeconds": "IDEMPOTENCY_WINDOW_SECONDS",
+	}
+
+	for name, expected := range tests {
+		if snakeCase(name) != expected {
+			t.Errorf("snakeCase(%v) should return %v, not %v", name, expected, snakeCase(name))
+		}
+	}
+}
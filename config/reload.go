@@ -0,0 +1,37 @@
+package config
+
+import "reflect"
+
+// Reload Returns this configuration with the values of next that can be
+// changed while the service is running. These are the log level, the
+// password strength policies, the task duration, and the hash algorithm
+// parameters. The names of the other values that differ are also returned
+// because they only change after a restart.
+func (c *Config) Reload(next Config) (Config, []string) {
+	reloaded := *c
+
+	reloaded.LogLevel = next.LogLevel
+	reloaded.CheckPasswordStrength = next.CheckPasswordStrength
+	reloaded.PasswordStrength = next.PasswordStrength
+	reloaded.PasswordPolicies = next.PasswordPolicies
+	reloaded.DefaultPolicy = next.DefaultPolicy
+	reloaded.BreachThreshold = next.BreachThreshold
+	reloaded.MaxTaskSeconds = next.MaxTaskSeconds
+	reloaded.HashAlgorithm = next.HashAlgorithm
+	reloaded.Argon2 = next.Argon2
+	reloaded.Bcrypt = next.Bcrypt
+	reloaded.Scrypt = next.Scrypt
+	reloaded.PBKDF2 = next.PBKDF2
+	reloaded.PepperFile = next.PepperFile
+
+	var restart []string
+	current := reflect.ValueOf(reloaded)
+	wanted := reflect.ValueOf(next)
+	for i := 0; i < current.NumField(); i++ {
+		if !reflect.DeepEqual(current.Field(i).Interface(), wanted.Field(i).Interface()) {
+			restart = append(restart, current.Type().Field(i).Name)
+		}
+	}
+
+	return reloaded, restart
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/logs"
+)
+
+func TestConfig_Reload(t *testing.T) {
+	current := defaultConfig()
+
+	next := *defaultConfig()
+	next.LogLevel = logs.DEBUG
+	next.PasswordStrength.MinLength = 12
+	next.MaxTaskSeconds = 1
+	next.Bcrypt.Cost = 12
+	next.Workers = 8
+	next.ServerAddress = ":9090"
+
+	reloaded, restart := current.Reload(next)
+
+	if reloaded.LogLevel != logs.DEBUG || reloaded.PasswordStrength.MinLength != 12 ||
+		reloaded.MaxTaskSeconds != 1 || reloaded.Bcrypt.Cost != 12 {
+		t.Errorf("Reload should change the reloadable values: %+v", reloaded)
+	}
+
+	if reloaded.Workers != current.Workers || reloaded.ServerAddress != current.ServerAddress {
+		t.Errorf("Reload should keep the values that need a restart: %+v", reloaded)
+	}
+
+	expected := []string{"Workers", "ServerAddress"}
+	if !reflect.DeepEqual(restart, expected) {
+		t.Errorf("Reload should return %v, not %v", expected, restart)
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jrpalma/pwdhash/logs"
+	"github.com/jrpalma/pwdhash/strength"
+)
+
+// FieldError A problem with a configuration value.
+type FieldError struct {
+	// Field The path of the value. For example: PasswordStrength.MaxLength.
+	Field string
+	// Message The reason the value is invalid.
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors Every problem found in a configuration.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	problems := make([]string, len(e))
+	for i, err := range e {
+		problems[i] = err.Error()
+	}
+	return "config: Invalid configuration:\n  " + strings.Join(problems, "\n  ")
+}
+
+// Add Adds a problem with the value at the field path.
+func (e *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err Returns the problems as an error or nil if there are none.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate Checks every configuration value and reports all the problems
+// at once. The returned error is ValidationErrors if a value is invalid.
+// The hash algorithm and ID scheme are checked by the task package because
+// hash algorithms can be registered at runtime.
+func (c *Config) Validate() error {
+	errs := ValidationErrors{}
+
+	switch c.LogLevel {
+	case logs.ERROR, logs.WARN, logs.INFO, logs.DEBUG:
+	default:
+		errs.Add("LogLevel", "must be ERROR, WARN, INFO, or DEBUG, not %q", c.LogLevel)
+	}
+
+	switch c.LogDestination {
+	case logs.STDOUT, logs.STDERR:
+	case logs.FILE:
+		if c.LogFile == "" {
+			errs.Add("LogFile", "must be set when LogDestination is FILE")
+		}
+	default:
+		errs.Add("LogDestination", "must be STDOUT, STDERR, or FILE, not %q", c.LogDestination)
+	}
+
+	if c.CheckPasswordStrength {
+		validatePolicy(&errs, "PasswordStrength", c.PasswordStrength)
+
+		names := make([]string, 0, len(c.PasswordPolicies))
+		for name := range c.PasswordPolicies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			validatePolicy(&errs, fmt.Sprintf("PasswordPolicies[%v]", name), c.PasswordPolicies[name])
+		}
+	}
+
+	if _, _, exists := c.Policy(""); !exists {
+		errs.Add("DefaultPolicy", "policy %q does not exist", c.DefaultPolicy)
+	}
+
+	if c.Argon2.Parallelism > 0 && c.Argon2.Memory > 0 && c.Argon2.Memory < 8*uint32(c.Argon2.Parallelism) {
+		errs.Add("Argon2.Memory", "must be at least 8 KiB per thread")
+	}
+	if c.Argon2.SaltLength > 0 && c.Argon2.SaltLength < 8 {
+		errs.Add("Argon2.SaltLength", "must be at least 8 bytes")
+	}
+	if c.Argon2.KeyLength > 0 && c.Argon2.KeyLength < 16 {
+		errs.Add("Argon2.KeyLength", "must be at least 16 bytes")
+	}
+	if c.Bcrypt.Cost != 0 && (c.Bcrypt.Cost < 4 || c.Bcrypt.Cost > 31) {
+		errs.Add("Bcrypt.Cost", "must be between 4 and 31, not %v", c.Bcrypt.Cost)
+	}
+	if c.Scrypt.LogN > 62 {
+		errs.Add("Scrypt.LogN", "must be at most 62, not %v", c.Scrypt.LogN)
+	}
+	if c.Scrypt.BlockSize < 0 {
+		errs.Add("Scrypt.BlockSize", "must not be negative")
+	}
+	if c.Scrypt.Parallelism < 0 {
+		errs.Add("Scrypt.Parallelism", "must not be negative")
+	}
+	if c.PBKDF2.Iterations < 0 {
+		errs.Add("PBKDF2.Iterations", "must not be negative")
+	}
+
+	_, port, err := net.SplitHostPort(c.ServerAddress)
+	if err != nil {
+		errs.Add("ServerAddress", "must be host:port, not %q", c.ServerAddress)
+	} else if _, err := net.LookupPort("tcp", port); err != nil {
+		errs.Add("ServerAddress", "has an invalid port %q", port)
+	}
+
+	return errs.Err()
+}
+
+// validatePolicy Checks the password strength rules of a policy.
+func validatePolicy(errs *ValidationErrors, path string, rules strength.PasswordStrength) {
+	if rules.MaxLength == 0 {
+		errs.Add(path+".MaxLength", "must be set because every password is longer than zero")
+	} else if rules.MaxLength < rules.MinLength {
+		errs.Add(path+".MaxLength", "must be at least MinLength %v, not %v", rules.MinLength, rules.MaxLength)
+	}
+
+	classes := rules.MinLowerCase + rules.MinUpperCase + rules.MinDigits + rules.MinSpecial
+	if rules.MaxLength > 0 && classes > rules.MaxLength {
+		errs.Add(path, "requires %v characters but MaxLength is %v", classes, rules.MaxLength)
+	}
+
+	if rules.MinScore > 4 {
+		errs.Add(path+".MinScore", "must be between 0 and 4, not %v", rules.MinScore)
+	}
+
+	for i, name := range rules.SpecialCategories {
+		if _, exists := unicode.Categories[name]; !exists {
+			errs.Add(fmt.Sprintf("%v.SpecialCategories[%v]", path, i), "unknown Unicode category %q", name)
+		}
+	}
+}
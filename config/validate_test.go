@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/logs"
+	"github.com/jrpalma/pwdhash/strength"
+)
+
+func TestValidate_Defaults(t *testing.T) {
+	err := defaultConfig().Validate()
+	if err != nil {
+		t.Errorf("Validate should accept the defaults: %v", err)
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	conf := defaultConfig()
+	conf.LogLevel = "LOUD"
+	conf.LogDestination = logs.FILE
+	conf.PasswordStrength.MaxLength = 6
+	conf.PasswordStrength.SpecialCategories = []string{"P", "Symbols"}
+	conf.PasswordPolicies = map[string]strength.PasswordStrength{
+		"admin": {MinLength: 16, MaxLength: 64, MinScore: 5},
+	}
+	conf.DefaultPolicy = "user"
+	conf.Bcrypt.Cost = 40
+	conf.ServerAddress = "localhost"
+
+	err := conf.Validate()
+	errs := ValidationErrors{}
+	if !errors.As(err, &errs) {
+		t.Errorf("Validate should return ValidationErrors, not %v", err)
+		return
+	}
+
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+
+	expected := []string{
+		"LogLevel",
+		"LogFile",
+		"PasswordStrength.MaxLength",
+		"PasswordStrength.SpecialCategories[1]",
+		"PasswordPolicies[admin].MinScore",
+		"DefaultPolicy",
+		"Bcrypt.Cost",
+		"ServerAddress",
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Validate should report %v, not %v", expected, fields)
+	}
+}
+
+func TestValidate_PasswordStrengthDisabled(t *testing.T) {
+	conf := defaultConfig()
+	conf.CheckPasswordStrength = false
+	conf.PasswordStrength.MaxLength = 0
+
+	err := conf.Validate()
+	if err != nil {
+		t.Errorf("Validate should not check the disabled strength rules: %v", err)
+	}
+}
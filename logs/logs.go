@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"time"
 )
 
@@ -88,45 +89,59 @@ func NewFileLogger(filePath string, level LogLevel) (Logger, error) {
 	return log, nil
 }
 
+// LevelSetter Changes the log level of a logger while it is in use.
+type LevelSetter interface {
+	// SetLevel Sets the log level. This call returns an error if the level is invalid.
+	SetLevel(level LogLevel) error
+}
+
 type logger struct {
 	file  io.Writer
-	level int
+	level int32
+}
+
+func (l *logger) SetLevel(level LogLevel) error {
+	if level != ERROR && level != WARN && level != INFO && level != DEBUG {
+		return fmt.Errorf("logs: Invalid log level")
+	}
+	atomic.StoreInt32(&l.level, logLevelToInt(level))
+	return nil
 }
 
 func (l *logger) Errorf(format string, args ...interface{}) {
-	if errorLevel < l.level || l.file == nil {
+	if errorLevel < atomic.LoadInt32(&l.level) || l.file == nil {
 		return
 	}
 	fmt.Fprint(l.file, getMessage(format, args...))
 }
 func (l *logger) Warnf(format string, args ...interface{}) {
-	if warnLevel < l.level || l.file == nil {
+	if warnLevel < atomic.LoadInt32(&l.level) || l.file == nil {
 		return
 	}
 	fmt.Fprint(l.file, getMessage(format, args...))
 }
 func (l *logger) Infof(format string, args ...interface{}) {
-	if infoLevel < l.level || l.file == nil {
+	if infoLevel < atomic.LoadInt32(&l.level) || l.file == nil {
 		return
 	}
 	fmt.Fprint(l.file, getMessage(format, args...))
 }
 func (l *logger) Debugf(format string, args ...interface{}) {
-	if debugLevel < l.level || l.file == nil {
+	if debugLevel < atomic.LoadInt32(&l.level) || l.file == nil {
 		return
 	}
 	fmt.Fprint(l.file, getMessage(format, args...))
 }
 
 const (
-	errorLevel int = 4
-	warnLevel  int = 3
-	infoLevel  int = 2
-	debugLevel int = 1
+	errorLevel int32 = 4
+	warnLevel  int32 = 3
+	infoLevel  int32 = 2
+	debugLevel int32 = 1
 )
 
-func logLevelToInt(level LogLevel) int {
-	var value int
+func logLevelToInt(level LogLevel) int32 {
+	var value int32
 	switch level {
 	case "ERROR":
 		value = errorLevel
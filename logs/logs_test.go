@@ -181,3 +181,37 @@ func TestNewFileLogger_Success(t *testing.T) {
 	}
 
 }
+
+func TestLogger_SetLevel(t *testing.T) {
+	log, err := NewStreamLogger(STDOUT, ERROR)
+	if err != nil {
+		t.Errorf("NewStreamLogger failed: %v", err)
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	imp := log.(*logger)
+	imp.file = buff
+
+	setter, ok := log.(LevelSetter)
+	if !ok {
+		t.Errorf("The stream logger should be a LevelSetter")
+		return
+	}
+
+	log.Infof("Hidden")
+	err = setter.SetLevel(INFO)
+	if err != nil {
+		t.Errorf("SetLevel failed: %v", err)
+	}
+	log.Infof("Shown")
+
+	if strings.Contains(buff.String(), "Hidden") || !strings.Contains(buff.String(), "Shown") {
+		t.Errorf("SetLevel should change the printed messages: %q", buff.String())
+	}
+
+	err = setter.SetLevel(LogLevel("INVALID"))
+	if err == nil {
+		t.Errorf("SetLevel should fail with invalid log level")
+	}
+}
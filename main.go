@@ -1,9 +1,15 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/jrpalma/pwdhash/config"
 	"github.com/jrpalma/pwdhash/logs"
 	"github.com/jrpalma/pwdhash/rest"
+	"github.com/jrpalma/pwdhash/task"
 )
 
 func getLog(conf config.Config) (logs.Logger, error) {
@@ -22,11 +28,29 @@ func getLog(conf config.Config) (logs.Logger, error) {
 }
 
 func main() {
-	conf := config.Config{}
+	conf, sources, err := config.Load(os.Args[1:], os.Environ())
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	err := conf.OpenFile("config.json")
+	if len(sources.Args) > 0 {
+		err = runCommand(conf, sources, sources.Args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = task.ValidateConfig(conf)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	log, err := getLog(conf)
@@ -34,6 +58,11 @@ func main() {
 		panic(err)
 	}
 
-	server := rest.NewServer(conf, log)
+	server, err := rest.NewServer(conf, log)
+	if err != nil {
+		panic(err)
+	}
+
+	watchConfig(server, sources, log)
 	server.Run()
 }
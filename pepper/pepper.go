@@ -0,0 +1,151 @@
+package pepper
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// KeyRing Represents a set of versioned pepper keys. New hashes are
+// always peppered with the current key while older keys are kept so
+// existing hashes can still be verified.
+type KeyRing struct {
+	// Current The version of the key used to pepper new hashes.
+	Current uint
+
+	// Keys The pepper keys indexed by their version.
+	Keys map[uint][]byte
+}
+
+// OpenFile Opens and loads a key ring file. This function returns an error
+// if the file cannot be read or the current key does not exist.
+func OpenFile(filePath string) (*KeyRing, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{}
+	err = json.Unmarshal(content, ring)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := ring.Keys[ring.Current]; !exists {
+		return nil, fmt.Errorf("pepper: Missing current key version %v", ring.Current)
+	}
+
+	return ring, nil
+}
+
+// SaveFile Saves the key ring to the filePath. The file is only
+// readable and writable by its owner. The file is replaced atomically
+// so a crash or a concurrent read never sees a partially written ring.
+func (k *KeyRing) SaveFile(filePath string) error {
+	bytes, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filePath, bytes)
+}
+
+// writeFileAtomic Writes the data to a temporary file and renames
+// it to filePath once it has been synced to disk.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp := filePath + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, filePath)
+}
+
+// Rotate Adds a new random key and makes it the current key. The
+// previous keys are kept. The version of the new key is returned.
+func (k *KeyRing) Rotate() (uint, error) {
+	key := make([]byte, keyLength)
+	_, err := rand.Read(key)
+	if err != nil {
+		return 0, fmt.Errorf("pepper: Failed to generate key: %v", err)
+	}
+
+	if k.Keys == nil {
+		k.Keys = make(map[uint][]byte)
+	}
+
+	version := k.Current
+	for v := range k.Keys {
+		if v > version {
+			version = v
+		}
+	}
+	version++
+
+	k.Keys[version] = key
+	k.Current = version
+
+	return version, nil
+}
+
+// Apply Peppers the password with the key of the given version. The
+// pepper is the base-64 encoded HMAC-SHA256 of the password so that it
+// can be used by any hash algorithm.
+func (k *KeyRing) Apply(version uint, password []byte) ([]byte, error) {
+	key, exists := k.Keys[version]
+	if !exists {
+		return nil, fmt.Errorf("pepper: Unknown key version %v", version)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(password)
+	sum := mac.Sum(nil)
+
+	peppered := make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+	base64.StdEncoding.Encode(peppered, sum)
+
+	return peppered, nil
+}
+
+// RotateFile Adds a new current key to the key ring file. The file is
+// created if it does not exist. The version of the new key is returned.
+func RotateFile(filePath string) (uint, error) {
+	ring, err := OpenFile(filePath)
+	if os.IsNotExist(err) {
+		ring, err = &KeyRing{}, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := ring.Rotate()
+	if err != nil {
+		return 0, err
+	}
+
+	return version, ring.SaveFile(filePath)
+}
+
+const (
+	keyLength = 32
+)
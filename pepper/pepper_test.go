@@ -0,0 +1,132 @@
+package pepper
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRotateFile(t *testing.T) {
+	file := "./RotateFile"
+	defer os.Remove(file)
+
+	version, err := RotateFile(file)
+	if err != nil || version != 1 {
+		t.Errorf("RotateFile failed: %v %v", version, err)
+		return
+	}
+
+	version, err = RotateFile(file)
+	if err != nil || version != 2 {
+		t.Errorf("RotateFile failed: %v %v", version, err)
+		return
+	}
+
+	ring, err := OpenFile(file)
+	if err != nil {
+		t.Errorf("OpenFile failed: %v", err)
+		return
+	}
+	if ring.Current != 2 || len(ring.Keys) != 2 || len(ring.Keys[1]) != keyLength {
+		t.Errorf("Invalid key ring: %+v", ring)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Key ring should only be accessible by its owner: %v", err)
+	}
+}
+
+func TestSaveFile_Atomic(t *testing.T) {
+	file := "./SaveFile_Atomic"
+	defer os.Remove(file)
+
+	_, err := RotateFile(file)
+	if err != nil {
+		t.Errorf("RotateFile failed: %v", err)
+		return
+	}
+
+	// A failed save keeps the previous key ring
+	err = os.Mkdir(file+".tmp", 0700)
+	if err != nil {
+		t.Errorf("Mkdir failed: %v", err)
+		return
+	}
+	_, err = RotateFile(file)
+	os.Remove(file + ".tmp")
+	if err == nil {
+		t.Errorf("RotateFile should fail when the file cannot be written")
+	}
+
+	ring, err := OpenFile(file)
+	if err != nil || ring.Current != 1 {
+		t.Errorf("A failed save should keep the previous key ring: %+v %v", ring, err)
+	}
+
+	_, err = RotateFile(file)
+	if err != nil {
+		t.Errorf("RotateFile failed: %v", err)
+	}
+	_, err = os.Stat(file + ".tmp")
+	if !os.IsNotExist(err) {
+		t.Errorf("SaveFile should not leave the temporary file: %v", err)
+	}
+}
+
+func TestOpenFile_Fail(t *testing.T) {
+	file := "./OpenFile_Fail"
+	defer os.Remove(file)
+
+	_, err := OpenFile(file)
+	if err == nil {
+		t.Errorf("OpenFile should fail with a missing file")
+	}
+
+	err = ioutil.WriteFile(file, []byte("{badJSON}"), 0600)
+	if err != nil {
+		t.Errorf("WriteFile failed: %v", err)
+		return
+	}
+	_, err = OpenFile(file)
+	if err == nil {
+		t.Errorf("OpenFile should fail with bad JSON")
+	}
+
+	err = ioutil.WriteFile(file, []byte(`{"Current":3,"Keys":{}}`), 0600)
+	if err != nil {
+		t.Errorf("WriteFile failed: %v", err)
+		return
+	}
+	_, err = OpenFile(file)
+	if err == nil {
+		t.Errorf("OpenFile should fail with a missing current key")
+	}
+}
+
+func TestApply(t *testing.T) {
+	ring := &KeyRing{}
+	ring.Rotate()
+	ring.Rotate()
+
+	first, err := ring.Apply(1, []byte("angryMonkey"))
+	if err != nil {
+		t.Errorf("Apply failed: %v", err)
+		return
+	}
+	second, err := ring.Apply(2, []byte("angryMonkey"))
+	if err != nil {
+		t.Errorf("Apply failed: %v", err)
+		return
+	}
+	again, _ := ring.Apply(1, []byte("angryMonkey"))
+
+	if string(first) == string(second) || string(first) != string(again) {
+		t.Errorf("Each key version should produce a different pepper")
+	}
+
+	_, err = ring.Apply(3, []byte("angryMonkey"))
+	if err == nil {
+		t.Errorf("Apply should fail with an unknown version")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/logs"
+	"github.com/jrpalma/pwdhash/rest"
+)
+
+// watchConfig Reloads the configuration when the process receives SIGHUP
+// or the configuration file changes. The configuration is loaded again
+// from every layer so the environment variables and flags still apply.
+func watchConfig(server *rest.Server, sources config.Sources, log logs.Logger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(configPollInterval)
+	last, _ := os.Stat(sources.File)
+
+	go func() {
+		for {
+			select {
+			case <-hangup:
+				log.Infof("Received SIGHUP")
+			case <-ticker.C:
+				info, err := os.Stat(sources.File)
+				if err != nil || !fileChanged(last, info) {
+					continue
+				}
+				last = info
+				log.Infof("Configuration file %v changed", sources.File)
+			}
+
+			reloadConfig(server, log)
+		}
+	}()
+}
+
+// reloadConfig Loads the configuration and applies it to the server. The
+// file is never written by a reload.
+func reloadConfig(server *rest.Server, log logs.Logger) {
+	args := append(os.Args[1:len(os.Args):len(os.Args)], "--no-write")
+
+	conf, _, err := config.Load(args, os.Environ())
+	if err != nil {
+		log.Errorf("Failed to reload configuration: %v", err)
+		return
+	}
+
+	server.Reload(conf)
+}
+
+// fileChanged Returns true if the file was created, modified, or replaced.
+func fileChanged(last, current os.FileInfo) bool {
+	if last == nil {
+		return true
+	}
+	return !current.ModTime().Equal(last.ModTime()) || current.Size() != last.Size() ||
+		!os.SameFile(last, current)
+}
+
+// configPollInterval How often the configuration file is checked for changes.
+const configPollInterval = 2 * time.Second
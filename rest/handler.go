@@ -3,6 +3,7 @@ package rest
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
 	"strings"
 
@@ -13,17 +14,20 @@ import (
 
 type serverShutdownFunc func() error
 
-func newHandler(conf config.Config, log logs.Logger, serverShutdown serverShutdownFunc) *handler {
+func newHandler(conf config.Config, log logs.Logger, serverShutdown serverShutdownFunc) (*handler, error) {
+	taskMgr, err := task.OpenManager(conf)
+	if err != nil {
+		return nil, err
+	}
+
 	return &handler{
-		taskMgr:        task.NewManager(conf),
+		taskMgr:        taskMgr,
 		serverShutdown: serverShutdown,
-		config:         conf,
 		log:            log,
-	}
+	}, nil
 }
 
 type handler struct {
-	config         config.Config
 	log            logs.Logger
 	taskMgr        *task.Manager
 	serverShutdown serverShutdownFunc
@@ -37,6 +41,58 @@ func (h *handler) newHash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	req, ok := h.readHashRequest(w, r, callInfo)
+	if !ok {
+		return
+	}
+	req.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
+
+	res := h.taskMgr.NewTask(req)
+	if res.Code != http.StatusCreated {
+		h.sendTaskResult(w, callInfo, res)
+		return
+	}
+
+	w.Header().Set("Location", v1+"/hash/"+res.Message)
+
+	if acceptsJSON(r.Header.Get("Accept")) {
+		status, _ := h.taskMgr.Status(res.Message)
+		h.sendJSON(w, callInfo, res.Code, status)
+		return
+	}
+
+	h.sendTaskResult(w, callInfo, res)
+}
+func (h *handler) checkStrength(w http.ResponseWriter, r *http.Request) {
+	callInfo := h.getCallInfo(r)
+
+	if r.Method != "POST" {
+		h.sendStatus(w, callInfo, http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, ok := h.readHashRequest(w, r, callInfo)
+	if !ok {
+		return
+	}
+
+	check, res := h.taskMgr.CheckStrength(req)
+	if res.Code != http.StatusOK {
+		h.sendTaskResult(w, callInfo, res)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	h.sendJSON(w, callInfo, http.StatusOK, check)
+}
+func (h *handler) verify(w http.ResponseWriter, r *http.Request) {
+	callInfo := h.getCallInfo(r)
+
+	if r.Method != "POST" {
+		h.sendStatus(w, callInfo, http.StatusMethodNotAllowed)
+		return
+	}
+
 	err := r.ParseForm()
 	if err != nil {
 		h.sendStatus(w, callInfo, http.StatusInternalServerError)
@@ -44,16 +100,51 @@ func (h *handler) newHash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !r.PostForm.Has(formFieldName) {
+	if !r.PostForm.Has(formFieldName) || !r.PostForm.Has(formFieldHash) {
 		h.sendStatus(w, callInfo, http.StatusBadRequest)
-		h.log.Errorf("%v Field password is missing", callInfo)
+		h.log.Errorf("%v Field password or hash is missing", callInfo)
 		return
 	}
 
-	pwd := r.PostForm.Get(formFieldName)
-	res := h.taskMgr.NewTask(pwd)
+	pwd := []byte(r.PostForm.Get(formFieldName))
+	hash := r.PostForm.Get(formFieldHash)
+	rehash := r.PostForm.Get(formFieldRehash) == "true"
+	verification, res := h.taskMgr.Verify(pwd, hash, rehash)
+	if res.Code != http.StatusOK {
+		h.sendTaskResult(w, callInfo, res)
+		return
+	}
 
-	h.sendTaskResult(w, callInfo, res)
+	h.sendJSON(w, callInfo, http.StatusOK, verification)
+}
+func (h *handler) needsRehash(w http.ResponseWriter, r *http.Request) {
+	callInfo := h.getCallInfo(r)
+
+	if r.Method != "POST" {
+		h.sendStatus(w, callInfo, http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		h.sendStatus(w, callInfo, http.StatusInternalServerError)
+		h.log.Errorf("%v Failed to parse form: %v", callInfo, err)
+		return
+	}
+
+	if !r.PostForm.Has(formFieldHash) {
+		h.sendStatus(w, callInfo, http.StatusBadRequest)
+		h.log.Errorf("%v Field hash is missing", callInfo)
+		return
+	}
+
+	check, res := h.taskMgr.NeedsRehash(r.PostForm.Get(formFieldHash))
+	if res.Code != http.StatusOK {
+		h.sendTaskResult(w, callInfo, res)
+		return
+	}
+
+	h.sendJSON(w, callInfo, http.StatusOK, check)
 }
 func (h *handler) checkHash(w http.ResponseWriter, r *http.Request) {
 	callInfo := h.getCallInfo(r)
@@ -66,13 +157,25 @@ func (h *handler) checkHash(w http.ResponseWriter, r *http.Request) {
 	tokens := strings.Split(r.URL.Path, "/")
 	hashID := tokens[len(tokens)-1]
 
+	if acceptsJSON(r.Header.Get("Accept")) {
+		status, res := h.taskMgr.Status(hashID)
+		if status.ID == "" {
+			h.sendTaskResult(w, callInfo, res)
+			return
+		}
+
+		if res.RetryAfter > 0 {
+			w.Header().Set("Retry-After", fmt.Sprintf("%v", res.RetryAfter))
+		}
+		h.sendJSON(w, callInfo, res.Code, status)
+		return
+	}
+
 	res := h.taskMgr.Check(hashID)
 
-	// TODO: We need to get a better approximation
-	// for the retry instead of using this value.
 	if res.Code == http.StatusServiceUnavailable {
-		seconds := fmt.Sprintf("%v", h.config.MaxTaskSeconds)
-		w.Header().Add("Retry-After", seconds)
+		estimate, _ := h.taskMgr.EstimateCompletion(hashID)
+		res.RetryAfter = task.RetryAfter(estimate)
 	}
 
 	h.sendTaskResult(w, callInfo, res)
@@ -91,7 +194,7 @@ func (h *handler) stats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.sendJSON(w, callInfo, stats)
+	h.sendJSON(w, callInfo, http.StatusOK, stats)
 }
 func (h *handler) shutdown(w http.ResponseWriter, r *http.Request) {
 	callInfo := h.getCallInfo(r)
@@ -111,29 +214,42 @@ func (h *handler) shutdown(w http.ResponseWriter, r *http.Request) {
 	go h.startShutdown(callInfo)
 }
 
-func (h *handler) startShutdown(rid string) {
+func (h *handler) startShutdown(rid call) {
 	h.log.Infof("%v Shutdown: Waiting for pending tasks...", rid)
 
 	h.taskMgr.WaitForPendingTasks()
 
+	err := h.taskMgr.Close()
+	if err != nil {
+		h.log.Errorf("%v Failed to close task store: %v", rid, err)
+	}
+
 	h.log.Infof("%v Shutdown: All tasks finished. Shutting down server...", rid)
-	err := h.serverShutdown()
+	err = h.serverShutdown()
 	if err != nil {
 		h.log.Errorf("%v Server failed to shutdown: %v", rid, err)
 	}
 }
 
-func (h *handler) sendStatus(w http.ResponseWriter, callInfo string, code int) {
-	http.Error(w, http.StatusText(code), code)
-	h.logCall(w, callInfo, code)
+func (h *handler) sendStatus(w http.ResponseWriter, callInfo call, code int) {
+	h.sendProblem(w, callInfo, newProblem(callInfo, task.Result{Code: code}))
 }
 
-func (h *handler) sendTaskResult(w http.ResponseWriter, callInfo string, result task.Result) {
+func (h *handler) sendTaskResult(w http.ResponseWriter, callInfo call, result task.Result) {
+	if result.RetryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprintf("%v", result.RetryAfter))
+	}
+
+	if result.Code >= http.StatusBadRequest {
+		h.sendProblem(w, callInfo, newProblem(callInfo, result))
+		return
+	}
+
 	http.Error(w, result.Message, result.Code)
 	h.logCall(w, callInfo, result.Code)
 }
 
-func (h *handler) sendJSON(w http.ResponseWriter, callInfo string, val interface{}) {
+func (h *handler) sendJSON(w http.ResponseWriter, callInfo call, code int, val interface{}) {
 	data, err := json.Marshal(val)
 	if err != nil {
 		res := task.Result{Message: err.Error(), Code: http.StatusInternalServerError}
@@ -141,13 +257,14 @@ func (h *handler) sendJSON(w http.ResponseWriter, callInfo string, val interface
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", jsonMediaType)
+	w.WriteHeader(code)
 	w.Write(data)
 
-	h.logCall(w, callInfo, http.StatusOK)
+	h.logCall(w, callInfo, code)
 }
 
-func (h *handler) logCall(w http.ResponseWriter, callInfo string, code int) {
+func (h *handler) logCall(w http.ResponseWriter, callInfo call, code int) {
 	success := code >= 200 && code <= 299
 	status := http.StatusText(code)
 	if success {
@@ -158,17 +275,140 @@ func (h *handler) logCall(w http.ResponseWriter, callInfo string, code int) {
 	h.log.Errorf("%v %v %v", callInfo, code, status)
 }
 
-func (h *handler) getCallInfo(r *http.Request) string {
+// hashRequest The JSON body used to create a new password hash. The
+// username, email, and display name are optional and only used to
+// reject passwords derived from them.
+type hashRequest struct {
+	Password    *string `json:"password"`
+	Algorithm   string  `json:"algorithm"`
+	Policy      string  `json:"policy"`
+	Username    string  `json:"username"`
+	Email       string  `json:"email"`
+	DisplayName string  `json:"displayName"`
+}
+
+// nonEmpty Returns the values that are not empty.
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// readHashRequest Reads the password, algorithm, policy, and user
+// context from a JSON or form body. An error response is sent if the
+// body is invalid.
+func (h *handler) readHashRequest(w http.ResponseWriter, r *http.Request, callInfo call) (task.Request, bool) {
+	req := task.Request{}
+
+	if isJSON(r.Header.Get("Content-Type")) {
+		// The body is limited like the form bodies parsed by ParseForm
+		body := hashRequest{}
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&body)
+		if err != nil {
+			h.sendStatus(w, callInfo, http.StatusBadRequest)
+			h.log.Errorf("%v Failed to decode JSON: %v", callInfo, err)
+			return req, false
+		}
+
+		if body.Password == nil {
+			h.sendStatus(w, callInfo, http.StatusBadRequest)
+			h.log.Errorf("%v Field password is missing", callInfo)
+			return req, false
+		}
+
+		req.Password = []byte(*body.Password)
+		req.Algorithm = body.Algorithm
+		req.Policy = body.Policy
+		req.Context = nonEmpty(body.Username, body.Email, body.DisplayName)
+	} else {
+		err := r.ParseForm()
+		if err != nil {
+			h.sendStatus(w, callInfo, http.StatusInternalServerError)
+			h.log.Errorf("%v Failed to parse form: %v", callInfo, err)
+			return req, false
+		}
+
+		if !r.PostForm.Has(formFieldName) {
+			h.sendStatus(w, callInfo, http.StatusBadRequest)
+			h.log.Errorf("%v Field password is missing", callInfo)
+			return req, false
+		}
+
+		req.Password = []byte(r.PostForm.Get(formFieldName))
+		req.Algorithm = r.PostForm.Get(formFieldAlgorithm)
+		req.Policy = r.PostForm.Get(formFieldPolicy)
+		req.Context = nonEmpty(
+			r.PostForm.Get(formFieldUsername),
+			r.PostForm.Get(formFieldEmail),
+			r.PostForm.Get(formFieldDisplayName),
+		)
+	}
+
+	return req, true
+}
+
+// isJSON Returns true if the Content-Type header is JSON.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == jsonMediaType
+}
+
+// acceptsJSON Returns true if the Accept header lists JSON. Plain
+// text is sent to the clients that do not ask for JSON.
+func acceptsJSON(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		if isJSON(strings.TrimSpace(mediaRange)) {
+			return true
+		}
+	}
+	return false
+}
+
+// call Identifies a request in the logs and in the error responses.
+type call struct {
+	requestID string
+	method    string
+	path      string
+}
+
+func (c call) String() string {
 	info := " "
-	val := r.Header.Get("X-Request-ID")
-	if val != "" {
-		info += fmt.Sprintf("%v(%v)", ridKey, val)
+	if c.requestID != "" {
+		info += fmt.Sprintf("%v(%v)", ridKey, c.requestID)
 	}
-	info += " " + r.Method
-	info += " " + r.URL.Path
+	info += " " + c.method
+	info += " " + c.path
 	return info
 }
 
+func (h *handler) getCallInfo(r *http.Request) call {
+	return call{
+		requestID: r.Header.Get(requestIDHeader),
+		method:    r.Method,
+		path:      r.URL.Path,
+	}
+}
+
 const (
-	formFieldName = "password"
+	formFieldName      = "password"
+	formFieldAlgorithm = "algorithm"
+	formFieldHash      = "hash"
+	formFieldRehash    = "rehash"
+	formFieldPolicy    = "policy"
+
+	formFieldUsername    = "username"
+	formFieldEmail       = "email"
+	formFieldDisplayName = "displayName"
+
+	idempotencyKeyHeader = "Idempotency-Key"
+	requestIDHeader      = "X-Request-ID"
+	jsonMediaType        = "application/json"
+
+	// maxBodySize The largest JSON request body. This is the limit
+	// ParseForm uses for the form bodies.
+	maxBodySize = 10 << 20
 )
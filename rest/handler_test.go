@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -41,12 +42,21 @@ func newHandlerHarness(api string) (*HandlerHarness, error) {
 	}
 
 	hh.log = log
-	hh.handler = newHandler(hh.conf, hh.log, hh.ts.Shutdown)
+	hh.handler, err = newHandler(hh.conf, hh.log, hh.ts.Shutdown)
+	if err != nil {
+		return nil, err
+	}
 
 	if api == "newHash" {
 		hh.server = httptest.NewServer(http.HandlerFunc(hh.handler.newHash))
 	} else if api == "checkHash" {
 		hh.server = httptest.NewServer(http.HandlerFunc(hh.handler.checkHash))
+	} else if api == "verify" {
+		hh.server = httptest.NewServer(http.HandlerFunc(hh.handler.verify))
+	} else if api == "needsRehash" {
+		hh.server = httptest.NewServer(http.HandlerFunc(hh.handler.needsRehash))
+	} else if api == "checkStrength" {
+		hh.server = httptest.NewServer(http.HandlerFunc(hh.handler.checkStrength))
 	} else if api == "stats" {
 		hh.server = httptest.NewServer(http.HandlerFunc(hh.handler.stats))
 	} else if api == "shutdown" {
@@ -78,10 +88,13 @@ func sendPost(URL string) (task.Result, error) {
 }
 
 func postPassword(field, pwd, method, URL string) (task.Result, error) {
-	res := task.Result{}
 	form := url.Values{}
 	form.Add(field, pwd)
+	return postForm(form, method, URL)
+}
 
+func postForm(form url.Values, method, URL string) (task.Result, error) {
+	res := task.Result{}
 	reqID := fmt.Sprintf("%v", rand.Intn(5000))
 
 	request, err := http.NewRequest(method, URL, strings.NewReader(form.Encode()))
@@ -187,6 +200,350 @@ func TestHandler_newHash(t *testing.T) {
 	}
 }
 
+func TestHandler_newHashUnknownAlgorithm(t *testing.T) {
+	h, err := newHandlerHarness("newHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	form := url.Values{}
+	form.Add("password", "secret")
+	form.Add("algorithm", "md5")
+	res, err := postForm(form, "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("newHash returned: %+v", res)
+	}
+}
+
+func TestHandler_newHashIdempotencyKey(t *testing.T) {
+	h := &HandlerHarness{}
+	log, err := logs.NewStreamLogger(logs.STDERR, logs.INFO)
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	h.log = log
+	h.conf.IdempotencyWindowSeconds = 60
+	h.handler, err = newHandler(h.conf, h.log, h.ts.Shutdown)
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	h.server = httptest.NewServer(http.HandlerFunc(h.handler.newHash))
+	defer h.server.Close()
+
+	post := func(pwd string) (*http.Response, error) {
+		form := url.Values{}
+		form.Add("password", pwd)
+		request, err := http.NewRequest("POST", h.server.URL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Add("Idempotency-Key", "key1")
+		return http.DefaultClient.Do(request)
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err := post("secret")
+		if err != nil {
+			t.Errorf("Failed to post password: %v", err)
+			return
+		}
+		response.Body.Close()
+
+		location := response.Header.Get("Location")
+		if response.StatusCode != http.StatusCreated || location != "/api/v1/hash/1" {
+			t.Errorf("newHash returned: %v %v", response.StatusCode, location)
+		}
+	}
+
+	response, err := post("different")
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("newHash returned: %v", response.StatusCode)
+	}
+}
+
+func TestHandler_newHashJSON(t *testing.T) {
+	h, err := newHandlerHarness("newHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	request := httptest.NewRequest("POST", "/api/v1/hash", strings.NewReader(`{"password":"secret","algorithm":"bcrypt"}`))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	request.Header.Set("Accept", "text/html, application/json")
+	response := httptest.NewRecorder()
+	h.handler.newHash(response, request)
+
+	status := task.TaskStatus{}
+	err = json.Unmarshal(response.Body.Bytes(), &status)
+	if response.Code != http.StatusCreated || err != nil {
+		t.Errorf("newHash returned: %v %v", response.Code, response.Body)
+		return
+	}
+
+	if status.ID != "1" || status.Algorithm != "bcrypt" || status.Created.IsZero() {
+		t.Errorf("newHash returned: %+v", status)
+	}
+
+	h.handler.taskMgr.WaitForPendingTasks()
+
+	request = httptest.NewRequest("GET", "/api/v1/hash/1", nil)
+	request.Header.Set("Accept", "application/json")
+	response = httptest.NewRecorder()
+	h.handler.checkHash(response, request)
+
+	status = task.TaskStatus{}
+	err = json.Unmarshal(response.Body.Bytes(), &status)
+	if response.Code != http.StatusOK || err != nil {
+		t.Errorf("checkHash returned: %v %v", response.Code, response.Body)
+		return
+	}
+
+	if status.Status != task.StatusDone || status.Hash == "" || status.Completed == nil {
+		t.Errorf("checkHash returned: %+v", status)
+	}
+}
+
+func TestHandler_newHashInvalidJSON(t *testing.T) {
+	h, err := newHandlerHarness("newHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	tooLarge := `{"password":"` + strings.Repeat("a", maxBodySize) + `"}`
+	for _, body := range []string{`{"password":`, `{"algorithm":"bcrypt"}`, tooLarge} {
+		request := httptest.NewRequest("POST", "/api/v1/hash", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response := httptest.NewRecorder()
+		h.handler.newHash(response, request)
+
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("newHash returned: %v for %.40v", response.Code, body)
+		}
+	}
+}
+
+func TestHandler_checkHashPlainText(t *testing.T) {
+	h, err := newHandlerHarness("checkHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	h.handler.taskMgr.NewTask(task.Request{Password: []byte("secret")})
+	h.handler.taskMgr.WaitForPendingTasks()
+
+	request := httptest.NewRequest("GET", "/api/v1/hash/1", nil)
+	response := httptest.NewRecorder()
+	h.handler.checkHash(response, request)
+
+	contentType := response.Header().Get("Content-Type")
+	if response.Code != http.StatusOK || !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("checkHash returned: %v %v", response.Code, contentType)
+	}
+}
+
+func TestHandler_verifyMethodNotAllowed(t *testing.T) {
+	h, err := newHandlerHarness("verify")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	res, err := getRequest(h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to get request: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("verify returned: %+v", res)
+	}
+}
+
+func TestHandler_verifyMissingField(t *testing.T) {
+	h, err := newHandlerHarness("verify")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	res, err := postPassword("password", "angryMonkey", "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("verify returned: %+v", res)
+	}
+}
+
+func TestHandler_verify(t *testing.T) {
+	h, err := newHandlerHarness("verify")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	form := url.Values{}
+	form.Add("password", "angryMonkey")
+	form.Add("hash", "ZEHhWB65gUlzdVwtDQArEyx+KVLzp/aTaRaPlBzYRIFj6vjFdqEb0Q5B8zVKCZ0vKbZPZklJz0Fd7su2A+gf7Q==")
+	res, err := postForm(form, "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusOK || res.Message != `{"match":true}` {
+		t.Errorf("verify returned: %+v", res)
+	}
+}
+
+func TestHandler_needsRehashMethodNotAllowed(t *testing.T) {
+	h, err := newHandlerHarness("needsRehash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	res, err := getRequest(h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to get request: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("needsRehash returned: %+v", res)
+	}
+}
+
+func TestHandler_needsRehash(t *testing.T) {
+	h, err := newHandlerHarness("needsRehash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	res, err := postPassword("hash", "$2a$04$aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post hash: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusOK || res.Message != `{"needsRehash":true}` {
+		t.Errorf("needsRehash returned: %+v", res)
+	}
+
+	res, err = postPassword("password", "secret", "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("needsRehash returned: %+v", res)
+	}
+}
+
+func TestHandler_checkStrengthMethodNotAllowed(t *testing.T) {
+	h, err := newHandlerHarness("checkStrength")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	res, err := getRequest(h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to get request: %v", err)
+		return
+	}
+
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Errorf("checkStrength returned: %+v", res)
+	}
+}
+
+func TestHandler_checkStrength(t *testing.T) {
+	h, err := newHandlerHarness("checkStrength")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	h.conf.CheckPasswordStrength = true
+	h.conf.PasswordStrength.MinLength = 8
+	h.conf.PasswordStrength.MaxLength = 50
+	h.conf.PasswordStrength.MinScore = 3
+	h.handler.taskMgr = task.NewManager(h.conf)
+
+	res, err := postForm(url.Values{"password": {"qwerty"}, "username": {"alice"}}, "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+
+	check := task.StrengthCheck{}
+	err = json.Unmarshal([]byte(res.Message), &check)
+	if res.Code != http.StatusOK || err != nil {
+		t.Errorf("checkStrength returned: %+v", res)
+		return
+	}
+
+	if check.Passed || check.Score != 0 || len(check.Violations) != 2 || len(check.Suggestions) == 0 {
+		t.Errorf("checkStrength should fail a keyboard pattern: %+v", check)
+	}
+
+	request := httptest.NewRequest("POST", "/api/v1/strength", strings.NewReader(`{"password":"correct horse battery staple"}`))
+	request.Header.Set("Content-Type", "application/json")
+	response := httptest.NewRecorder()
+	h.handler.checkStrength(response, request)
+
+	check = task.StrengthCheck{}
+	json.Unmarshal(response.Body.Bytes(), &check)
+	if response.Code != http.StatusOK || !check.Passed || check.Score != 4 {
+		t.Errorf("checkStrength should pass a passphrase: %v %+v", response.Code, check)
+	}
+
+	if response.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("checkStrength should not be cached")
+	}
+
+	stats, _ := h.handler.taskMgr.Stats()
+	if stats.Total != 0 {
+		t.Errorf("checkStrength should not create tasks: %+v", stats)
+	}
+}
+
 func TestHandler_checkHashMethodNotAllowed(t *testing.T) {
 	h, err := newHandlerHarness("checkHash")
 	if err != nil {
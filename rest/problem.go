@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jrpalma/pwdhash/strength"
+	"github.com/jrpalma/pwdhash/task"
+)
+
+// problem An error response as defined by RFC 7807. The request ID is
+// sent so a failure reported by a client can be matched to the logs.
+type problem struct {
+	// Type The URI that identifies the kind of failure.
+	Type string `json:"type"`
+	// Title The summary of the kind of failure.
+	Title string `json:"title"`
+	// Status The HTTP status code.
+	Status int `json:"status"`
+	// Detail The explanation of this occurrence of the failure.
+	Detail string `json:"detail,omitempty"`
+	// RequestID The X-Request-ID of the failed request.
+	RequestID string `json:"requestId,omitempty"`
+	// Violations The strength rules a weak password does not pass.
+	Violations []strength.Violation `json:"violations,omitempty"`
+	// Estimate The estimated strength of a weak password.
+	Estimate *strength.Estimate `json:"estimate,omitempty"`
+}
+
+// newProblem Returns the problem describing the failed task result. The
+// problem type is about:blank if the failure is only described by the
+// status code.
+func newProblem(callInfo call, result task.Result) problem {
+	p := problem{
+		Type:      problemAboutBlank,
+		Title:     http.StatusText(result.Code),
+		Status:    result.Code,
+		Detail:    result.Message,
+		RequestID: callInfo.requestID,
+	}
+
+	if result.Strength != nil {
+		p.Violations = result.Strength.Violations
+		p.Estimate = result.Strength.Estimate
+	}
+
+	title, known := problemTitles[result.Problem]
+	if known {
+		p.Type = problemTypePrefix + result.Problem
+		p.Title = title
+	}
+
+	return p
+}
+
+func (h *handler) sendProblem(w http.ResponseWriter, callInfo call, p problem) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		h.logCall(w, callInfo, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", problemMediaType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(data)
+
+	h.logCall(w, callInfo, p.Status)
+}
+
+// problemTitles The titles of the problem types.
+var problemTitles = map[string]string{
+	task.ProblemWeakPassword: "Password is too weak",
+	task.ProblemShutdown:     "Service is shutting down",
+	task.ProblemUnknownID:    "Unknown hash ID",
+	task.ProblemQueueFull:    "Too many pending tasks",
+}
+
+const (
+	problemMediaType  = "application/problem+json"
+	problemAboutBlank = "about:blank"
+	// problemTypePrefix The prefix of the problem type URIs. The URIs
+	// identify the problem types and cannot be dereferenced.
+	problemTypePrefix = "urn:pwdhash:problem:"
+)
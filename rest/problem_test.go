@@ -0,0 +1,161 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/strength"
+	"github.com/jrpalma/pwdhash/task"
+)
+
+func TestProblem_UnknownID(t *testing.T) {
+	h, err := newHandlerHarness("checkHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	request := httptest.NewRequest("GET", "/api/v1/hash/100", nil)
+	request.Header.Set("X-Request-ID", "42")
+	response := httptest.NewRecorder()
+	h.handler.checkHash(response, request)
+
+	p := problem{}
+	err = json.Unmarshal(response.Body.Bytes(), &p)
+	if err != nil {
+		t.Errorf("checkHash should return a problem: %v", response.Body)
+		return
+	}
+
+	if response.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Invalid content type: %v", response.Header().Get("Content-Type"))
+	}
+
+	expected := problem{
+		Type:      "urn:pwdhash:problem:unknown-id",
+		Title:     "Unknown hash ID",
+		Status:    http.StatusNotFound,
+		Detail:    "No such hash ID 100",
+		RequestID: "42",
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("checkHash should return %+v, not %+v", expected, p)
+	}
+}
+
+func TestProblem_Status(t *testing.T) {
+	h, err := newHandlerHarness("newHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	request := httptest.NewRequest("GET", "/api/v1/hash", nil)
+	response := httptest.NewRecorder()
+	h.handler.newHash(response, request)
+
+	p := problem{}
+	json.Unmarshal(response.Body.Bytes(), &p)
+
+	expected := problem{
+		Type:   "about:blank",
+		Title:  "Method Not Allowed",
+		Status: http.StatusMethodNotAllowed,
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("newHash should return %+v, not %+v", expected, p)
+	}
+}
+
+func TestProblem_Types(t *testing.T) {
+	problems := map[string]int{
+		task.ProblemWeakPassword: http.StatusBadRequest,
+		task.ProblemShutdown:     http.StatusInternalServerError,
+		task.ProblemUnknownID:    http.StatusNotFound,
+		task.ProblemQueueFull:    http.StatusServiceUnavailable,
+	}
+
+	for name, code := range problems {
+		p := newProblem(call{requestID: "1"}, task.Result{Code: code, Problem: name})
+		if p.Type != "urn:pwdhash:problem:"+name || p.Title == "" || p.Status != code {
+			t.Errorf("Invalid %v problem: %+v", name, p)
+		}
+	}
+}
+
+func TestProblem_WeakPassword(t *testing.T) {
+	h, err := newHandlerHarness("newHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	h.conf.CheckPasswordStrength = true
+	h.conf.PasswordStrength.MinDigits = 1
+	h.conf.PasswordStrength.MinLength = 8
+	h.conf.PasswordStrength.MaxLength = 50
+	h.handler.taskMgr = task.NewManager(h.conf)
+
+	res, err := postPassword("password", "secret", "POST", h.server.URL)
+	if err != nil {
+		t.Errorf("Failed to post password: %v", err)
+		return
+	}
+
+	p := problem{}
+	json.Unmarshal([]byte(res.Message), &p)
+
+	expected := []strength.Violation{
+		{Rule: strength.RuleMinDigits, Required: 1, Actual: 0},
+		{Rule: strength.RuleMinLength, Required: 8, Actual: 6},
+	}
+	if res.Code != http.StatusBadRequest || !reflect.DeepEqual(p.Violations, expected) {
+		t.Errorf("newHash should return the violations %+v, not %+v", expected, p)
+	}
+}
+
+func TestProblem_PasswordContext(t *testing.T) {
+	h, err := newHandlerHarness("newHash")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	defer h.server.Close()
+	h.conf.CheckPasswordStrength = true
+	h.conf.PasswordStrength.MaxLength = 50
+	h.conf.PasswordStrength.ContextDistance = 2
+	h.handler.taskMgr = task.NewManager(h.conf)
+
+	body := `{"password":"Alice2024","username":"alice","email":"alice@example.com"}`
+	request := httptest.NewRequest("POST", "/api/v1/hash", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	response := httptest.NewRecorder()
+	h.handler.newHash(response, request)
+
+	p := problem{}
+	json.Unmarshal(response.Body.Bytes(), &p)
+
+	expected := []strength.Violation{{Rule: strength.RuleContext, Required: 3, Actual: 0}}
+	if response.Code != http.StatusBadRequest || !reflect.DeepEqual(p.Violations, expected) {
+		t.Errorf("newHash should return the violations %+v, not %+v", expected, p)
+	}
+
+	form := url.Values{"password": {"alicia"}, "displayName": {"Alice Liddell"}}
+	request = httptest.NewRequest("POST", "/api/v1/hash", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response = httptest.NewRecorder()
+	h.handler.newHash(response, request)
+
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("newHash should return 400, not %v", response.Code)
+	}
+}
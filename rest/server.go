@@ -4,19 +4,29 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jrpalma/pwdhash/config"
 	"github.com/jrpalma/pwdhash/logs"
+	"github.com/jrpalma/pwdhash/task"
 )
 
-func NewServer(conf config.Config, log logs.Logger) *Server {
+func NewServer(conf config.Config, log logs.Logger) (*Server, error) {
+	var err error
+
 	server := &Server{log: log}
-	server.handler = newHandler(conf, log, server.Shutdown)
+	server.handler, err = newHandler(conf, log, server.Shutdown)
+	if err != nil {
+		return nil, err
+	}
 
 	server.mux = http.NewServeMux()
 	server.mux.HandleFunc(v1+"/hash", server.handler.newHash)
 	server.mux.HandleFunc(v1+"/hash/", server.handler.checkHash)
+	server.mux.HandleFunc(v1+"/hash/needs-rehash", server.handler.needsRehash)
+	server.mux.HandleFunc(v1+"/verify", server.handler.verify)
+	server.mux.HandleFunc(v1+"/strength", server.handler.checkStrength)
 	server.mux.HandleFunc(v1+"/stats", server.handler.stats)
 	server.mux.HandleFunc(v1+"/shutdown", server.handler.shutdown)
 
@@ -25,7 +35,7 @@ func NewServer(conf config.Config, log logs.Logger) *Server {
 		Handler: server,
 	}
 
-	return server
+	return server, nil
 }
 
 type Server struct {
@@ -44,6 +54,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.requestID++
 	}
 
+	w.Header().Set("X-Request-ID", rid)
 	ctx := context.WithValue(r.Context(), ridKey, rid)
 
 	start := time.Now()
@@ -69,6 +80,30 @@ func (s *Server) Shutdown() error {
 	return err
 }
 
+// Reload Validates the configuration and applies the values that can be
+// changed while the server is running. The current configuration is kept
+// if the new one is invalid. The values that require a restart are logged.
+func (s *Server) Reload(conf config.Config) error {
+	err := task.ValidateConfig(conf)
+	if err != nil {
+		s.log.Errorf("Failed to reload configuration: %v", err)
+		return err
+	}
+
+	restart := s.handler.taskMgr.Reload(conf)
+
+	if setter, ok := s.log.(logs.LevelSetter); ok {
+		setter.SetLevel(conf.LogLevel)
+	}
+
+	s.log.Infof("Reloaded configuration")
+	if len(restart) > 0 {
+		s.log.Warnf("Restart required to apply: %v", strings.Join(restart, ", "))
+	}
+
+	return nil
+}
+
 const (
 	ridKey = "REQID"
 	v1     = "/api/v1"
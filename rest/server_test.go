@@ -24,7 +24,10 @@ func newServerHarness(addr string) (*ServerHarness, error) {
 	}
 	h.log = log
 	h.conf.ServerAddress = addr
-	h.server = NewServer(h.conf, h.log)
+	h.server, err = NewServer(h.conf, h.log)
+	if err != nil {
+		return nil, err
+	}
 
 	return h, nil
 }
@@ -102,3 +105,25 @@ func TestServer_RunRequest(t *testing.T) {
 		t.Errorf("stats returned: %+v", res)
 	}
 }
+
+func TestServer_Reload(t *testing.T) {
+	sh, err := newServerHarness(":3703")
+	if err != nil {
+		t.Errorf("Failed to setup test: %v", err)
+		return
+	}
+
+	conf := sh.conf
+	conf.LogLevel = "VERBOSE"
+	err = sh.server.Reload(conf)
+	if err == nil {
+		t.Errorf("Reload should fail for an invalid log level")
+	}
+
+	conf.LogLevel = logs.DEBUG
+	conf.LogDestination = logs.STDERR
+	err = sh.server.Reload(conf)
+	if err != nil {
+		t.Errorf("Reload should not fail: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package strength
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+const (
+	// BreachSHA1 The blocklist has the SHA-1 hashes of the passwords.
+	BreachSHA1 = "sha1"
+	// BreachNTLM The blocklist has the NTLM hashes of the passwords.
+	BreachNTLM = "ntlm"
+)
+
+// Blocklist A list of breached password hashes kept on disk. The file has
+// one "HASH:count" line per password sorted by hash like the Have I Been
+// Pwned downloads. The hashes are either SHA-1 or NTLM and the kind is
+// detected from the first line. Lookups do a binary search on the file so
+// the list is never loaded in memory.
+type Blocklist struct {
+	// Kind The kind of hashes in the file. This is BreachSHA1 or BreachNTLM.
+	Kind string
+
+	file *os.File
+	size int64
+}
+
+// OpenBlocklist Opens a breached password file. This function returns an
+// error if the file cannot be opened or the first line is not a SHA-1 or
+// NTLM hash.
+func OpenBlocklist(filePath string) (*Blocklist, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	b := &Blocklist{file: file, size: info.Size()}
+
+	_, line, err := b.lineAt(0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	hash, _ := splitLine(line)
+	switch len(hash) {
+	case sha1.Size * 2:
+		b.Kind = BreachSHA1
+	case md4.Size * 2:
+		b.Kind = BreachNTLM
+	default:
+		file.Close()
+		return nil, fmt.Errorf("strength: Unknown hash in blocklist %v", filePath)
+	}
+
+	return b, nil
+}
+
+// Close Closes the blocklist file.
+func (b *Blocklist) Close() error {
+	return b.file.Close()
+}
+
+// Count Returns the number of times the password was seen in breaches.
+// Zero is returned if the password is not in the list.
+func (b *Blocklist) Count(password []byte) (uint, error) {
+	target := []byte(hex.EncodeToString(b.hash(password)))
+	upper(target)
+
+	// The line holding the target, if any, starts in [lo, hi)
+	lo, hi := int64(0), b.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := b.lineAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		hash, count := splitLine(line)
+		upper(hash)
+
+		switch bytes.Compare(hash, target) {
+		case 0:
+			n, err := strconv.ParseUint(string(bytes.TrimSpace(count)), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("strength: Invalid count in blocklist: %q", count)
+			}
+			return uint(n), nil
+		case -1:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return 0, nil
+}
+
+func (b *Blocklist) hash(password []byte) []byte {
+	if b.Kind == BreachNTLM {
+		// NTLM is the MD4 hash of the UTF-16LE password
+		units := utf16.Encode([]rune(string(password)))
+		encoded := make([]byte, len(units)*2)
+		for i, unit := range units {
+			binary.LittleEndian.PutUint16(encoded[i*2:], unit)
+		}
+		defer zeroBytes(encoded)
+
+		h := md4.New()
+		h.Write(encoded)
+		return h.Sum(nil)
+	}
+
+	sum := sha1.Sum(password)
+	return sum[:]
+}
+
+// lineAt Returns the first line that starts at or after offset and its
+// start. The start is the file size if there is no such line.
+func (b *Blocklist) lineAt(offset int64) (int64, []byte, error) {
+	start := offset
+	if offset > 0 {
+		// The line starts after the newline that precedes offset
+		start = offset - 1
+	}
+
+	buf := make([]byte, maxBlocklistLine*2)
+	n, err := b.file.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	buf = buf[:n]
+
+	if offset > 0 {
+		newline := bytes.IndexByte(buf, '\n')
+		if newline < 0 {
+			if n < len(buf) {
+				return b.size, nil, nil
+			}
+			return 0, nil, fmt.Errorf("strength: Blocklist line is too long at %v", start)
+		}
+		start += int64(newline) + 1
+		buf = buf[newline+1:]
+	}
+
+	end := bytes.IndexByte(buf, '\n')
+	if end < 0 {
+		if start+int64(len(buf)) < b.size {
+			return 0, nil, fmt.Errorf("strength: Blocklist line is too long at %v", start)
+		}
+		end = len(buf)
+	}
+
+	return start, buf[:end], nil
+}
+
+// splitLine Returns the hash and the count of a "HASH:count" line.
+func splitLine(line []byte) ([]byte, []byte) {
+	line = bytes.TrimRight(line, "\r")
+	colon := bytes.IndexByte(line, ':')
+	if colon < 0 {
+		return line, nil
+	}
+	return line[:colon], line[colon+1:]
+}
+
+func upper(hash []byte) {
+	for i, c := range hash {
+		if c >= 'a' && c <= 'f' {
+			hash[i] = c - 'a' + 'A'
+		}
+	}
+}
+
+func zeroBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+// maxBlocklistLine The longest expected "HASH:count" line.
+const maxBlocklistLine = 128
@@ -0,0 +1,111 @@
+package strength
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeBlocklist Writes a sorted SHA-1 blocklist with the passwords and
+// their counts. Random hashes are added around them.
+func writeBlocklist(t *testing.T, counts map[string]uint) string {
+	var lines []string
+	for password, count := range counts {
+		sum := sha1.Sum([]byte(password))
+		lines = append(lines, fmt.Sprintf("%X:%v", sum, count))
+	}
+	for i := 0; i < 1000; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("filler%v", i)))
+		lines = append(lines, fmt.Sprintf("%X:%v", sum, i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+	return path
+}
+
+func TestBlocklist_Count(t *testing.T) {
+	counts := map[string]uint{"password": 9545824, "123456": 37359195, "letmein": 1}
+	list, err := OpenBlocklist(writeBlocklist(t, counts))
+	if err != nil {
+		t.Errorf("OpenBlocklist failed: %v", err)
+		return
+	}
+	defer list.Close()
+
+	if list.Kind != BreachSHA1 {
+		t.Errorf("OpenBlocklist should detect %v, not %v", BreachSHA1, list.Kind)
+	}
+
+	for password, count := range counts {
+		n, err := list.Count([]byte(password))
+		if err != nil || n != count {
+			t.Errorf("Count(%q) should return %v, not %v: %v", password, count, n, err)
+		}
+	}
+
+	for i := 0; i < 1000; i += 97 {
+		n, err := list.Count([]byte(fmt.Sprintf("filler%v", i)))
+		if err != nil || n != uint(i+1) {
+			t.Errorf("Count(filler%v) should return %v, not %v: %v", i, i+1, n, err)
+		}
+	}
+
+	n, err := list.Count([]byte("correct horse battery staple"))
+	if err != nil || n != 0 {
+		t.Errorf("Count should return 0 for a password not in the list, not %v: %v", n, err)
+	}
+}
+
+func TestBlocklist_NTLM(t *testing.T) {
+	content := "32ED87BDB5FDC5E9CBA88547376818D4:37359195\n" +
+		"8846F7EAEE8FB117AD06BDD830B7586C:9545824"
+
+	path := filepath.Join(t.TempDir(), "ntlm.txt")
+	ioutil.WriteFile(path, []byte(content), 0600)
+
+	list, err := OpenBlocklist(path)
+	if err != nil {
+		t.Errorf("OpenBlocklist failed: %v", err)
+		return
+	}
+	defer list.Close()
+
+	if list.Kind != BreachNTLM {
+		t.Errorf("OpenBlocklist should detect %v, not %v", BreachNTLM, list.Kind)
+	}
+
+	n, err := list.Count([]byte("password"))
+	if err != nil || n != 9545824 {
+		t.Errorf("Count should return 9545824, not %v: %v", n, err)
+	}
+
+	n, err = list.Count([]byte("123456"))
+	if err != nil || n != 37359195 {
+		t.Errorf("Count should return 37359195, not %v: %v", n, err)
+	}
+}
+
+func TestOpenBlocklist_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.txt")
+	ioutil.WriteFile(path, []byte(hex.EncodeToString([]byte("short"))+":1\n"), 0600)
+
+	_, err := OpenBlocklist(path)
+	if err == nil {
+		t.Errorf("OpenBlocklist should fail with an unknown hash")
+	}
+
+	_, err = OpenBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+	if err == nil {
+		t.Errorf("OpenBlocklist should fail with a missing file")
+	}
+}
@@ -0,0 +1,121 @@
+package strength
+
+import (
+	"strings"
+	"unicode"
+)
+
+// contextDistance Returns the smallest edit distance between the password
+// and the context values. The distance is zero if the password contains a
+// value. The password is compared case folded and reversed. The distances
+// greater than limit are not computed so only a lower bound greater than
+// limit is returned for them. This function returns false if there is no
+// value to compare.
+func contextDistance(password string, context []string, limit uint) (uint, bool) {
+	values := contextValues(context)
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	folded := []rune(strings.ToLower(password))
+	variants := [][]rune{folded, []rune(reverse(folded))}
+
+	distance := ^uint(0)
+	for _, value := range values {
+		for _, variant := range variants {
+			if strings.Contains(string(variant), string(value)) {
+				return 0, true
+			}
+
+			// The length difference is a lower bound of the distance
+			d := lengthDifference(variant, value)
+			if d <= limit {
+				d = editDistance(variant, value)
+			}
+			if d < distance {
+				distance = d
+			}
+		}
+	}
+
+	return distance, true
+}
+
+// contextValues Returns the case folded values a password should not be
+// derived from. Names are also split into words and the local part of
+// an email is added. Values that are too short are ignored so common
+// letters are not rejected.
+func contextValues(context []string) [][]rune {
+	var values [][]rune
+	add := func(value string) {
+		runes := []rune(value)
+		if len(runes) >= minContextLength {
+			values = append(values, runes)
+		}
+	}
+
+	for _, value := range context {
+		value = strings.ToLower(strings.TrimSpace(value))
+		add(value)
+
+		if at := strings.LastIndexByte(value, '@'); at > 0 {
+			add(value[:at])
+			value = value[:at]
+		}
+
+		words := strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) > 1 {
+			add(strings.Join(words, ""))
+			for _, word := range words {
+				add(word)
+			}
+		}
+	}
+
+	return values
+}
+
+// editDistance Returns the Levenshtein distance between a and b.
+func editDistance(a, b []rune) uint {
+	previous := make([]uint, len(b)+1)
+	current := make([]uint, len(b)+1)
+	for j := range previous {
+		previous[j] = uint(j)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = uint(i)
+		for j := 1; j <= len(b); j++ {
+			cost := uint(1)
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func lengthDifference(a, b []rune) uint {
+	if len(a) > len(b) {
+		return uint(len(a) - len(b))
+	}
+	return uint(len(b) - len(a))
+}
+
+func min3(a, b, c uint) uint {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// minContextLength The shortest context value that is checked.
+const minContextLength = 3
@@ -0,0 +1,91 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate_Context(t *testing.T) {
+	rules := PasswordStrength{MaxLength: 50, ContextDistance: 2}
+	context := []string{"jsmith", "john.smith@example.com", "John Smith"}
+
+	tests := []struct {
+		password string
+		distance uint
+	}{
+		{"xJSmith2024!", 0},
+		{"htimsj", 0},
+		{"Smith", 0},
+		{"jsmyth", 1},
+		{"jsmith", 0},
+		{"Jon Smyth", 2},
+	}
+
+	for _, test := range tests {
+		report := Evaluate(rules, test.password, context...)
+		violation := Violation{RuleContext, 3, test.distance}
+		if len(report.Violations) != 1 || report.Violations[0] != violation {
+			t.Errorf("Evaluate(%q) should return %+v, not %+v", test.password, violation, report.Violations)
+		}
+	}
+
+	if !Check(rules, "correct horse battery staple", context...) {
+		t.Errorf("Check should pass a password unrelated to the context")
+	}
+
+	if !Check(rules, "jsmith") {
+		t.Errorf("Check should pass without a context")
+	}
+}
+
+func TestEvaluate_ContextWords(t *testing.T) {
+	rules := PasswordStrength{MaxLength: 50, ContextWords: []string{"pwdhash"}}
+
+	report := Evaluate(rules, "MyPwdHash1!")
+	if report.Passed {
+		t.Errorf("Evaluate should fail a password with the service name")
+	}
+
+	// Short values are ignored
+	if !Check(rules, "ab", "a", "b") {
+		t.Errorf("Check should ignore short context values")
+	}
+}
+
+func TestEvaluate_ContextTooLong(t *testing.T) {
+	rules := PasswordStrength{MaxLength: 50, ContextDistance: 2}
+	password := strings.Repeat("jsmith", 1<<20)
+
+	// The context is not compared once the password is too long
+	report := Evaluate(rules, password, "jsmith", "john.smith@example.com")
+	violation := Violation{RuleMaxLength, 50, 6 << 20}
+	if len(report.Violations) != 1 || report.Violations[0] != violation {
+		t.Errorf("Evaluate should only return %+v, not %+v", violation, report.Violations)
+	}
+
+	// The distances above the limit are only bounded by the length difference
+	distance, _ := contextDistance(strings.Repeat("x", 1000), []string{"jsmith"}, 2)
+	if distance != 994 {
+		t.Errorf("contextDistance should return the length difference 994, not %v", distance)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance uint
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"héllo", "hello", 1},
+	}
+
+	for _, test := range tests {
+		d := editDistance([]rune(test.a), []rune(test.b))
+		if d != test.distance {
+			t.Errorf("editDistance(%q, %q) should return %v, not %v", test.a, test.b, test.distance, d)
+		}
+	}
+}
@@ -0,0 +1,730 @@
+package strength
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Estimate The estimated strength of a password. The estimate is based
+// on the number of guesses an attacker that knows the common password
+// patterns needs to find the password.
+type Estimate struct {
+	// Guesses The estimated number of guesses needed to find the password.
+	Guesses float64 `json:"guesses"`
+	// Score The strength score from 0 (too guessable) to 4 (very unguessable).
+	Score uint `json:"score"`
+	// CrackTimeSeconds The estimated number of seconds needed to find the
+	// password offline when it is hashed with a slow hash function.
+	CrackTimeSeconds float64 `json:"crackTimeSeconds"`
+	// CrackTime The crack time in words. For example: "3 hours".
+	CrackTime string `json:"crackTime"`
+	// Patterns The patterns found in the password in order.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// Entropy Estimates the strength of the password similar to zxcvbn. The
+// password is split into the dictionary words, keyboard walks, repeats,
+// sequences, and dates that need the least guesses to find. The rest of
+// the password is assumed to be random.
+func Entropy(password string) Estimate {
+	runes := []rune(password)
+
+	// Only the start of very long passwords is matched to bound the work.
+	// The rest is assumed to be random.
+	extra := 0
+	if len(runes) > maxEstimateLength {
+		extra = len(runes) - maxEstimateLength
+		runes = runes[:maxEstimateLength]
+	}
+
+	e := &estimator{bases: make(map[string]float64)}
+	logGuesses, patterns := e.minimumGuesses(runes)
+	logGuesses += float64(extra) * math.Log10(bruteforceCardinality)
+
+	estimate := Estimate{Patterns: patterns}
+	estimate.Guesses = math.Pow(10, logGuesses)
+	estimate.Score = score(estimate.Guesses)
+	estimate.CrackTimeSeconds = estimate.Guesses / offlineGuessesPerSecond
+	estimate.CrackTime = crackTime(estimate.CrackTimeSeconds)
+
+	return estimate
+}
+
+// match A part of the password that follows a pattern.
+type match struct {
+	pattern string
+	// i, j The indexes of the first and last characters.
+	i, j    int
+	guesses float64
+}
+
+type estimator struct {
+	// bases The guesses of the repeated parts of the password.
+	bases map[string]float64
+}
+
+// minimumGuesses Returns the base-10 logarithm of the number of guesses
+// needed for the sequence of matches that covers the password with the
+// least guesses. Like zxcvbn, a sequence of k matches needs
+// k! * product(guesses) + 10000^(k-1) guesses since the attacker does not
+// know the number of patterns.
+func (e *estimator) minimumGuesses(runes []rune) (float64, []string) {
+	n := len(runes)
+	if n == 0 {
+		return 0, nil
+	}
+
+	ending := make([][]match, n)
+	for _, m := range e.matches(runes) {
+		ending[m.j] = append(ending[m.j], m)
+	}
+
+	// best[j][k] The lowest log10 product of guesses for k matches that
+	// cover the password up to j. The last match is kept to rebuild the
+	// sequence.
+	type step struct {
+		log  float64
+		last match
+		set  bool
+	}
+	best := make([][]step, n)
+	for j := range best {
+		best[j] = make([]step, n+2)
+	}
+
+	extend := func(m match) {
+		log := math.Log10(m.guesses)
+		if m.i == 0 {
+			if !best[m.j][1].set || log < best[m.j][1].log {
+				best[m.j][1] = step{log, m, true}
+			}
+			return
+		}
+		for k, prev := range best[m.i-1] {
+			if !prev.set || k+1 >= len(best[m.j]) {
+				continue
+			}
+			candidate := prev.log + log
+			if !best[m.j][k+1].set || candidate < best[m.j][k+1].log {
+				best[m.j][k+1] = step{candidate, m, true}
+			}
+		}
+	}
+
+	for j := 0; j < n; j++ {
+		for _, m := range ending[j] {
+			extend(m)
+		}
+		for i := 0; i <= j; i++ {
+			extend(match{pattern: patternBruteforce, i: i, j: j, guesses: math.Pow(bruteforceCardinality, float64(j-i+1))})
+		}
+	}
+
+	total, count := math.Inf(1), 0
+	for k, s := range best[n-1] {
+		if !s.set {
+			continue
+		}
+		sequence := logSum(logFactorial(k)+s.log, float64(k-1)*math.Log10(minGuessesBeforeGrowingSequence))
+		if sequence < total {
+			total, count = sequence, k
+		}
+	}
+
+	patterns := make([]string, count)
+	for j, k := n-1, count; k > 0; k-- {
+		m := best[j][k].last
+		patterns[k-1] = m.pattern
+		j = m.i - 1
+	}
+
+	return total, patterns
+}
+
+// matches Returns every part of the password that follows a pattern.
+func (e *estimator) matches(runes []rune) []match {
+	var matches []match
+
+	matches = append(matches, dictionaryMatches(runes)...)
+	matches = append(matches, spatialMatches(runes)...)
+	matches = append(matches, e.repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, dateMatches(runes)...)
+
+	// A pattern is never easier to guess than a few random characters
+	for i := range matches {
+		min := float64(minSubmatchGuessesMultiChar)
+		if matches[i].i == matches[i].j {
+			min = minSubmatchGuessesSingleChar
+		}
+		matches[i].guesses = math.Max(matches[i].guesses, min)
+	}
+
+	return matches
+}
+
+// dictionaryMatches Matches the dictionary words including the reversed
+// words and the words with l33t substitutions.
+func dictionaryMatches(runes []rune) []match {
+	var matches []match
+
+	lower := []rune(strings.ToLower(string(runes)))
+	for i := range lower {
+		for j := i + minWordLength - 1; j < len(lower) && j-i < maxWordLength; j++ {
+			word := lower[i : j+1]
+			variations := upperVariations(runes[i : j+1])
+
+			if rank, found := dictionary[string(word)]; found {
+				matches = append(matches, match{patternDictionary, i, j, float64(rank) * variations})
+			}
+
+			if rank, found := dictionary[reverse(word)]; found {
+				matches = append(matches, match{patternDictionary, i, j, float64(rank) * variations * 2})
+			}
+
+			for _, variant := range unleet(word) {
+				if rank, found := dictionary[variant.word]; found {
+					guesses := float64(rank) * variations * math.Pow(2, float64(variant.subs))
+					matches = append(matches, match{patternDictionary, i, j, guesses})
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// upperVariations Returns the number of ways the word could have been
+// capitalized using the common capitalizations first.
+func upperVariations(word []rune) float64 {
+	var upper, lower int
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 1
+	}
+	first, last := unicode.IsUpper(word[0]), unicode.IsUpper(word[len(word)-1])
+	if lower == 0 || (upper == 1 && (first || last)) {
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= upper && i <= lower; i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+// leetVariant A word with its l33t characters replaced by letters.
+type leetVariant struct {
+	word string
+	subs int
+}
+
+// unleet Returns the words that the l33t word could stand for. Nothing is
+// returned if the word has no l33t characters.
+func unleet(word []rune) []leetVariant {
+	variants := []leetVariant{{}}
+
+	for _, r := range word {
+		letters, isLeet := leetTable[r]
+		if !isLeet {
+			for i := range variants {
+				variants[i].word += string(r)
+			}
+			continue
+		}
+
+		var next []leetVariant
+		for _, variant := range variants {
+			for _, letter := range letters {
+				if len(next) < maxLeetVariants {
+					next = append(next, leetVariant{variant.word + string(letter), variant.subs + 1})
+				}
+			}
+		}
+		variants = next
+	}
+
+	if variants[0].subs == 0 {
+		return nil
+	}
+	return variants
+}
+
+// keyPosition The row and column of a key on a qwerty keyboard.
+type keyPosition struct {
+	row, column int
+	shifted     bool
+}
+
+// spatialMatches Matches the keyboard walks of at least 3 keys. For
+// example: "qwerty" and "zaq12wsx".
+func spatialMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(runes)-2; i++ {
+		j, turns, direction := i, 0, -1
+		shifted := 0
+		if keyboard[runes[i]].shifted {
+			shifted++
+		}
+
+		for j+1 < len(runes) {
+			next := neighbor(runes[j], runes[j+1])
+			if next < 0 {
+				break
+			}
+			if next != direction {
+				turns++
+				direction = next
+			}
+			if keyboard[runes[j+1]].shifted {
+				shifted++
+			}
+			j++
+		}
+
+		if j-i+1 >= 3 {
+			matches = append(matches, match{patternSpatial, i, j, spatialGuesses(j-i+1, turns, shifted)})
+		}
+	}
+
+	return matches
+}
+
+// neighbor Returns the direction of the key b from the key a. This call
+// returns -1 if the keys are not next to each other.
+func neighbor(a, b rune) int {
+	from, found := keyboard[a]
+	to, found2 := keyboard[b]
+	if !found || !found2 {
+		return -1
+	}
+
+	// Each row is shifted to the right of the row above it so the keys
+	// above are at the same and the next column, except for the first
+	// row of letters which is shifted by one more key.
+	above := func(row int) int {
+		if row == 1 {
+			return 1
+		}
+		return 0
+	}
+
+	neighbors := []keyPosition{
+		{from.row, from.column - 1, false},
+		{from.row, from.column + 1, false},
+		{from.row - 1, from.column + above(from.row), false},
+		{from.row - 1, from.column + above(from.row) + 1, false},
+		{from.row + 1, from.column - above(from.row+1) - 1, false},
+		{from.row + 1, from.column - above(from.row+1), false},
+	}
+
+	for direction, position := range neighbors {
+		if position.row == to.row && position.column == to.column {
+			return direction
+		}
+	}
+	return -1
+}
+
+// spatialGuesses Returns the guesses of a keyboard walk as in zxcvbn.
+func spatialGuesses(length, turns, shifted int) float64 {
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= turns && j <= i-1; j++ {
+			guesses += binomial(i-1, j-1) * keyboardStartingPositions * math.Pow(keyboardAverageDegree, float64(j))
+		}
+	}
+
+	unshifted := length - shifted
+	if shifted == 0 {
+		return guesses
+	}
+	if unshifted == 0 {
+		return guesses * 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= shifted && i <= unshifted; i++ {
+		variations += binomial(length, i)
+	}
+	return guesses * variations
+}
+
+// repeatMatches Matches the parts repeated at least twice that are at
+// least 3 characters long. For example: "aaa" and "abcabc". Like zxcvbn,
+// only the shortest repeated part and the one that covers the most
+// characters are matched and the search resumes after the repeat.
+func (e *estimator) repeatMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(runes); {
+		shortest, longest, longestCount := 0, 0, 0
+		for unit := 1; i+2*unit <= len(runes); unit++ {
+			count := 1
+			for i+(count+1)*unit <= len(runes) && equal(runes[i:i+unit], runes[i+count*unit:i+(count+1)*unit]) {
+				count++
+			}
+
+			if count < 2 || count*unit < 3 {
+				continue
+			}
+			if shortest == 0 {
+				shortest = unit
+			}
+			if count*unit > longest*longestCount {
+				longest, longestCount = unit, count
+			}
+		}
+
+		if shortest == 0 {
+			i++
+			continue
+		}
+
+		for _, unit := range []int{shortest, longest} {
+			count := longestCount
+			if unit == shortest {
+				count = repeats(runes[i:], unit)
+			}
+			guesses := e.baseGuesses(runes[i:i+unit]) * float64(count)
+			matches = append(matches, match{patternRepeat, i, i + count*unit - 1, guesses})
+		}
+		i += longest * longestCount
+	}
+
+	return matches
+}
+
+// repeats Returns the number of times the first unit characters are repeated.
+func repeats(runes []rune, unit int) int {
+	count := 1
+	for (count+1)*unit <= len(runes) && equal(runes[:unit], runes[count*unit:(count+1)*unit]) {
+		count++
+	}
+	return count
+}
+
+// baseGuesses Returns the guesses of the repeated part of the password.
+func (e *estimator) baseGuesses(unit []rune) float64 {
+	key := string(unit)
+	if guesses, found := e.bases[key]; found {
+		return guesses
+	}
+
+	log, _ := e.minimumGuesses(unit)
+	guesses := math.Pow(10, log)
+	e.bases[key] = guesses
+	return guesses
+}
+
+// sequenceMatches Matches the characters that follow each other in the
+// alphabet or in the digits. For example: "abcd", "9753", and "zyx".
+func sequenceMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(runes)-2; {
+		delta := int(runes[i+1]) - int(runes[i])
+		j := i + 1
+		for j+1 < len(runes) && int(runes[j+1])-int(runes[j]) == delta && sameClass(runes[j], runes[j+1]) {
+			j++
+		}
+
+		if j-i+1 >= 3 && delta != 0 && delta >= -maxSequenceDelta && delta <= maxSequenceDelta && sameClass(runes[i], runes[i+1]) {
+			matches = append(matches, match{patternSequence, i, j, sequenceGuesses(runes[i], j-i+1, delta)})
+			i = j
+			continue
+		}
+		i++
+	}
+
+	return matches
+}
+
+func sequenceGuesses(first rune, length, delta int) float64 {
+	base := 26.0
+	if strings.ContainsRune("aAzZ019", first) {
+		base = 4
+	} else if unicode.IsDigit(first) {
+		base = 10
+	}
+
+	if delta < 0 {
+		base *= 2
+	}
+	return base * float64(length)
+}
+
+func sameClass(a, b rune) bool {
+	return unicode.IsLower(a) && unicode.IsLower(b) ||
+		unicode.IsUpper(a) && unicode.IsUpper(b) ||
+		unicode.IsDigit(a) && unicode.IsDigit(b)
+}
+
+// dateMatches Matches the years and the dates with or without separators.
+// For example: "1987", "2/14/99", and "19870214".
+func dateMatches(runes []rune) []match {
+	var matches []match
+	reference := time.Now().Year()
+
+	for i := range runes {
+		for j := i + 3; j < len(runes) && j-i < maxDateLength; j++ {
+			text := string(runes[i : j+1])
+
+			if year, err := strconv.Atoi(text); err == nil && len(text) == 4 && year >= minYear && year <= maxYear {
+				guesses := math.Max(math.Abs(float64(year-reference)), minYearSpace)
+				matches = append(matches, match{patternDate, i, j, guesses})
+			}
+
+			year, separated, valid := parseDate(text)
+			if !valid {
+				continue
+			}
+
+			guesses := 365 * math.Max(math.Abs(float64(year-reference)), minYearSpace)
+			if separated {
+				guesses *= 4
+			}
+			matches = append(matches, match{patternDate, i, j, guesses})
+		}
+	}
+
+	return matches
+}
+
+// parseDate Returns the year of the date. The day, month, and year can be
+// in any of the common orders and the year can have 2 or 4 digits.
+func parseDate(text string) (int, bool, bool) {
+	if parts := dateSeparators.FindStringSubmatch(text); parts != nil {
+		if parts[2] != parts[4] {
+			return 0, false, false
+		}
+		year, valid := dateYear(parts[1], parts[3], parts[5])
+		return year, true, valid
+	}
+
+	for _, r := range text {
+		if !unicode.IsDigit(r) {
+			return 0, false, false
+		}
+	}
+
+	for a := 1; a < len(text)-1; a++ {
+		for b := a + 1; b < len(text); b++ {
+			if year, valid := dateYear(text[:a], text[a:b], text[b:]); valid {
+				return year, false, true
+			}
+		}
+	}
+	return 0, false, false
+}
+
+// dateYear Returns the year if the parts are a valid date in year, month,
+// day order or day, month, year order or month, day, year order.
+func dateYear(first, second, third string) (int, bool) {
+	year := func(part string) (int, bool) {
+		value, err := strconv.Atoi(part)
+		switch {
+		case err != nil:
+			return 0, false
+		case len(part) == 4 && value >= minYear && value <= maxYear:
+			return value, true
+		case len(part) == 2 && value > 50:
+			return 1900 + value, true
+		case len(part) == 2:
+			return 2000 + value, true
+		}
+		return 0, false
+	}
+
+	dayMonth := func(day, month string) bool {
+		d, err := strconv.Atoi(day)
+		m, err2 := strconv.Atoi(month)
+		return err == nil && err2 == nil && len(day) <= 2 && len(month) <= 2 &&
+			d >= 1 && d <= 31 && m >= 1 && m <= 12
+	}
+
+	if y, valid := year(first); valid && (dayMonth(third, second) || dayMonth(second, third)) {
+		return y, true
+	}
+	if y, valid := year(third); valid && (dayMonth(first, second) || dayMonth(second, first)) {
+		return y, true
+	}
+	return 0, false
+}
+
+// score Converts the guesses to the 0 to 4 score used by zxcvbn.
+func score(guesses float64) uint {
+	for i, threshold := range scoreThresholds {
+		if guesses < threshold {
+			return uint(i)
+		}
+	}
+	return uint(len(scoreThresholds))
+}
+
+// crackTime Returns the number of seconds in words.
+func crackTime(seconds float64) string {
+	units := []struct {
+		name    string
+		seconds float64
+	}{
+		{"year", 365 * 24 * 3600},
+		{"month", 31 * 24 * 3600},
+		{"day", 24 * 3600},
+		{"hour", 3600},
+		{"minute", 60},
+		{"second", 1},
+	}
+
+	if seconds < 1 {
+		return "less than a second"
+	}
+	if seconds >= 100*units[0].seconds {
+		return "centuries"
+	}
+
+	for _, unit := range units {
+		if seconds >= unit.seconds {
+			count := int(math.Round(seconds / unit.seconds))
+			if count == 1 {
+				return fmt.Sprintf("1 %v", unit.name)
+			}
+			return fmt.Sprintf("%v %vs", count, unit.name)
+		}
+	}
+	return "less than a second"
+}
+
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result *= float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+func logFactorial(n int) float64 {
+	lgamma, _ := math.Lgamma(float64(n + 1))
+	return lgamma / math.Ln10
+}
+
+// logSum Returns log10(10^a + 10^b) without overflowing.
+func logSum(a, b float64) float64 {
+	high, low := math.Max(a, b), math.Min(a, b)
+	return high + math.Log10(1+math.Pow(10, low-high))
+}
+
+func reverse(word []rune) string {
+	reversed := make([]rune, len(word))
+	for i, r := range word {
+		reversed[len(word)-1-i] = r
+	}
+	return string(reversed)
+}
+
+func equal(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+//go:embed dictionary.txt
+var dictionaryFile string
+
+// dictionary The rank of each dictionary word. The most common word has rank 1.
+var dictionary = loadDictionary(dictionaryFile)
+
+func loadDictionary(file string) map[string]int {
+	words := make(map[string]int)
+	for _, line := range strings.Split(file, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, found := words[word]; !found {
+			words[word] = len(words) + 1
+		}
+	}
+	return words
+}
+
+// keyboard The position of every key on a qwerty keyboard.
+var keyboard = func() map[rune]keyPosition {
+	rows := []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+	shiftedRows := []string{"~!@#$%^&*()_+", "QWERTYUIOP{}|", "ASDFGHJKL:\"", "ZXCVBNM<>?"}
+
+	keys := make(map[rune]keyPosition)
+	for row := range rows {
+		for column, key := range []rune(rows[row]) {
+			keys[key] = keyPosition{row, column, false}
+		}
+		for column, key := range []rune(shiftedRows[row]) {
+			keys[key] = keyPosition{row, column, true}
+		}
+	}
+	return keys
+}()
+
+// leetTable The letters that each l33t character can stand for.
+var leetTable = map[rune][]rune{
+	'4': {'a'}, '@': {'a'}, '8': {'b'}, '(': {'c'}, '{': {'c'}, '[': {'c'}, '<': {'c'},
+	'3': {'e'}, '6': {'g'}, '9': {'g'}, '1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'},
+	'0': {'o'}, '$': {'s'}, '5': {'s'}, '7': {'t', 'l'}, '+': {'t'}, '%': {'x'}, '2': {'z'},
+}
+
+// dateSeparators Matches the dates with separators such as "2/14/99".
+var dateSeparators = regexp.MustCompile(`^(\d{1,4})([\s/\\_.-])(\d{1,2})([\s/\\_.-])(\d{1,4})$`)
+
+// scoreThresholds The guesses needed for the scores 1 to 4.
+var scoreThresholds = []float64{1e3 + 5, 1e6 + 5, 1e8 + 5, 1e10 + 5}
+
+const (
+	patternDictionary = "dictionary"
+	patternSpatial    = "spatial"
+	patternRepeat     = "repeat"
+	patternSequence   = "sequence"
+	patternDate       = "date"
+	patternBruteforce = "bruteforce"
+
+	maxEstimateLength = 100
+	minWordLength     = 3
+	maxWordLength     = 16
+	maxLeetVariants   = 32
+	maxSequenceDelta  = 5
+	maxDateLength     = 10
+	minYear           = 1900
+	maxYear           = 2099
+	minYearSpace      = 20
+
+	bruteforceCardinality           = 10
+	minSubmatchGuessesSingleChar    = 10
+	minSubmatchGuessesMultiChar     = 50
+	minGuessesBeforeGrowingSequence = 10000
+
+	// keyboardStartingPositions and keyboardAverageDegree The number of
+	// keys and the average number of neighbors of a qwerty keyboard.
+	keyboardStartingPositions = 94
+	keyboardAverageDegree     = 4.6
+
+	// offlineGuessesPerSecond The guesses per second of an attacker with
+	// a stolen hash created by a slow hash function.
+	offlineGuessesPerSecond = 1e4
+)
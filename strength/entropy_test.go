@@ -0,0 +1,91 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEntropy_Scores(t *testing.T) {
+	tests := []struct {
+		password string
+		score    uint
+	}{
+		{"", 0},
+		{"password", 0},
+		{"p@ssw0rd", 0},
+		{"drowssap", 0},
+		{"qwerty", 0},
+		{"aaaaaaaaaaaa", 0},
+		{"abcdefgh", 0},
+		{"Password1!", 1},
+		{"zaq12wsx", 1},
+		{"12/25/1990", 1},
+		{"correct horse battery staple", 4},
+		{"x7#Kq9!vRz2$Lm", 4},
+	}
+
+	for _, test := range tests {
+		estimate := Entropy(test.password)
+		if estimate.Score != test.score {
+			t.Errorf("Entropy(%q) should return score %v, not %v: %+v", test.password, test.score, estimate.Score, estimate)
+		}
+	}
+}
+
+func TestEntropy_Patterns(t *testing.T) {
+	tests := []struct {
+		password string
+		pattern  string
+	}{
+		{"password", patternDictionary},
+		{"p@ssw0rd", patternDictionary},
+		{"dfghjkl;", patternSpatial},
+		{"abcabcabc", patternRepeat},
+		{"13579", patternSequence},
+		{"1990", patternDate},
+		{"x7#Kq9", patternBruteforce},
+	}
+
+	for _, test := range tests {
+		estimate := Entropy(test.password)
+		if len(estimate.Patterns) != 1 || estimate.Patterns[0] != test.pattern {
+			t.Errorf("Entropy(%q) should find the %v pattern, not %v", test.password, test.pattern, estimate.Patterns)
+		}
+	}
+}
+
+func TestEntropy_CrackTime(t *testing.T) {
+	estimate := Entropy("password")
+	if estimate.CrackTime != "less than a second" {
+		t.Errorf("Entropy should crack password instantly, not in %v", estimate.CrackTime)
+	}
+
+	estimate = Entropy(strings.Repeat("x7#Kq9!vRz2$Lm", 100))
+	if estimate.Score != 4 || estimate.CrackTime != "centuries" {
+		t.Errorf("Entropy should not crack a long random password: %+v", estimate)
+	}
+}
+
+func TestEvaluate_MinScore(t *testing.T) {
+	rules := PasswordStrength{MaxLength: 50, MinScore: 3}
+
+	report := Evaluate(rules, "Password1!")
+	if report.Passed || report.Estimate == nil {
+		t.Errorf("Evaluate should fail a common password: %+v", report)
+		return
+	}
+
+	violation := Violation{RuleMinScore, 3, report.Estimate.Score}
+	if len(report.Violations) != 1 || report.Violations[0] != violation {
+		t.Errorf("Evaluate should return %+v, not %+v", violation, report.Violations)
+	}
+
+	if !Check(rules, "correct horse battery staple") {
+		t.Errorf("Check should pass a passphrase with %+v", rules)
+	}
+
+	report = Evaluate(PasswordStrength{MaxLength: 50}, "password")
+	if report.Estimate != nil {
+		t.Errorf("Evaluate should not estimate the strength without MinScore: %+v", report)
+	}
+}
@@ -3,6 +3,8 @@ package strength
 import (
 	"strings"
 	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // PasswordStrength Represents the rules used to check password strength.
@@ -12,7 +14,7 @@ type PasswordStrength struct {
 	// MinUpperCase The maximum number of upper case characters in the password.
 	MinUpperCase uint
 	// MinSpecial The minimum number of special characters in the password.
-	// Special characters include: ~!@#$%^&*()_+-=
+	// Special characters are set by SpecialCharacters and SpecialCategories.
 	MinSpecial uint
 	// MinNumber The minimum number of digit characters in the password.
 	MinDigits uint
@@ -20,11 +22,67 @@ type PasswordStrength struct {
 	MinLength uint
 	// MaxLength The maximum password length. This is used to avoid extremely large passwords.
 	MaxLength uint
+	// MinScore The minimum entropy score from 0 to 4. The score is estimated by
+	// Entropy. The score is not checked if this is zero.
+	MinScore uint
+	// ContextDistance The edit distance within which a password is too
+	// similar to the user context such as the username or email. Passwords
+	// that contain a context value are always rejected.
+	ContextDistance uint
+	// ContextWords The words every password is compared with as part of
+	// the context. For example: the service name.
+	ContextWords []string
+	// SpecialCharacters The characters counted as special characters.
+	SpecialCharacters string
+	// SpecialCategories The Unicode categories counted as special characters.
+	// For example: P for punctuation and S for symbols. The special characters
+	// are ~!@#$%^&*()_+-= if this and SpecialCharacters are empty.
+	SpecialCategories []string
+	// ForbidControl Rejects passwords with control characters.
+	ForbidControl bool
+	// ForbidWhitespace Rejects passwords with whitespace characters.
+	ForbidWhitespace bool
+}
+
+// Violation A strength rule that the password does not pass.
+type Violation struct {
+	// Rule The name of the rule. This is one of the Rule constants.
+	Rule string `json:"rule"`
+	// Required The count required by the rule.
+	Required uint `json:"required"`
+	// Actual The count found in the password.
+	Actual uint `json:"actual"`
+}
+
+// Report The result of checking a password against the strength rules.
+type Report struct {
+	// Passed True if the password passes every rule.
+	Passed bool `json:"passed"`
+	// Violations The rules the password does not pass.
+	Violations []Violation `json:"violations,omitempty"`
+	// Estimate The estimated password strength. This is only
+	// set if the rules have a minimum score.
+	Estimate *Estimate `json:"estimate,omitempty"`
 }
 
 // Check Returns true if the password strength pass the strength rules.
-func Check(rules PasswordStrength, password string) bool {
+// The context is the user information such as the username, email, or
+// display name the password should not be derived from.
+func Check(rules PasswordStrength, password string, context ...string) bool {
+	return Evaluate(rules, password, context...).Passed
+}
+
+// Evaluate Checks the password against every strength rule and
+// reports each rule the password does not pass. The context is the
+// user information the password should not be derived from. The
+// password is NFKC normalized before it is checked so the characters
+// that look the same are counted the same.
+func Evaluate(rules PasswordStrength, password string, context ...string) Report {
 	var numLowerCase, numUpperCase, numDigits, numSpecial, length uint
+	var numControl, numWhitespace uint
+
+	password = norm.NFKC.String(password)
+	special := rules.specialTable()
 
 	codePoints := []rune(password)
 	for _, codePoint := range codePoints {
@@ -38,38 +96,115 @@ func Check(rules PasswordStrength, password string) bool {
 		if unicode.IsDigit(codePoint) {
 			numDigits++
 		}
-		if isSpecial(codePoint) {
+		if special(codePoint) {
 			numSpecial++
 		}
-
+		if unicode.IsControl(codePoint) {
+			numControl++
+		}
+		if unicode.IsSpace(codePoint) {
+			numWhitespace++
+		}
 	}
 
-	if numLowerCase < rules.MinLowerCase {
-		return false
-	}
-	if numUpperCase < rules.MinUpperCase {
-		return false
+	report := Report{}
+	atLeast := func(rule string, required, actual uint) {
+		if actual < required {
+			report.Violations = append(report.Violations, Violation{rule, required, actual})
+		}
 	}
-	if numDigits < rules.MinDigits {
-		return false
+	atMost := func(rule string, allowed, actual uint) {
+		if actual > allowed {
+			report.Violations = append(report.Violations, Violation{rule, allowed, actual})
+		}
 	}
-	if numSpecial < rules.MinSpecial {
-		return false
+
+	atLeast(RuleMinLowerCase, rules.MinLowerCase, numLowerCase)
+	atLeast(RuleMinUpperCase, rules.MinUpperCase, numUpperCase)
+	atLeast(RuleMinDigits, rules.MinDigits, numDigits)
+	atLeast(RuleMinSpecial, rules.MinSpecial, numSpecial)
+	atLeast(RuleMinLength, rules.MinLength, length)
+	atMost(RuleMaxLength, rules.MaxLength, length)
+	if rules.ForbidControl {
+		atMost(RuleControl, 0, numControl)
 	}
-	if length < rules.MinLength {
-		return false
+	if rules.ForbidWhitespace {
+		atMost(RuleWhitespace, 0, numWhitespace)
 	}
+
+	// A password that is too long is rejected without the costly checks
 	if length > rules.MaxLength {
-		return false
+		report.Passed = false
+		return report
 	}
 
-	return true
+	if rules.MinScore > 0 {
+		estimate := Entropy(password)
+		report.Estimate = &estimate
+		atLeast(RuleMinScore, rules.MinScore, estimate.Score)
+	}
+
+	values := append(append([]string{}, context...), rules.ContextWords...)
+	distance, compared := contextDistance(password, values, rules.ContextDistance)
+	if compared {
+		atLeast(RuleContext, rules.ContextDistance+1, distance)
+	}
+
+	report.Passed = len(report.Violations) == 0
+	return report
 }
 
+const (
+	// RuleMinLowerCase The password has too few lower case characters.
+	RuleMinLowerCase = "minLowerCase"
+	// RuleMinUpperCase The password has too few upper case characters.
+	RuleMinUpperCase = "minUpperCase"
+	// RuleMinDigits The password has too few digits.
+	RuleMinDigits = "minDigits"
+	// RuleMinSpecial The password has too few special characters.
+	RuleMinSpecial = "minSpecial"
+	// RuleMinLength The password is too short.
+	RuleMinLength = "minLength"
+	// RuleMaxLength The password is too long.
+	RuleMaxLength = "maxLength"
+	// RuleMinScore The password is too easy to guess.
+	RuleMinScore = "minScore"
+	// RuleBreached The password was seen in too many breaches.
+	RuleBreached = "breached"
+	// RuleContext The password is too similar to the user context. The
+	// counts are edit distances.
+	RuleContext = "context"
+	// RuleControl The password has control characters.
+	RuleControl = "control"
+	// RuleWhitespace The password has whitespace characters.
+	RuleWhitespace = "whitespace"
+)
+
 const (
 	specialChars = "~!@#$%^&*()_+-="
 )
 
+// specialTable Returns the function that tells if a character is a
+// special character. Unknown categories are ignored.
+func (rules PasswordStrength) specialTable() func(rune) bool {
+	if rules.SpecialCharacters == "" && len(rules.SpecialCategories) == 0 {
+		return isSpecial
+	}
+
+	var categories []*unicode.RangeTable
+	for _, name := range rules.SpecialCategories {
+		table, exists := unicode.Categories[name]
+		if exists {
+			categories = append(categories, table)
+		}
+	}
+
+	return func(codePoint rune) bool {
+		return strings.ContainsRune(rules.SpecialCharacters, codePoint) ||
+			unicode.IsOneOf(categories, codePoint)
+	}
+}
+
 func isSpecial(codePoint rune) bool {
 	return strings.Contains(specialChars, string(codePoint))
 }
@@ -1,6 +1,7 @@
 package strength
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -64,3 +65,92 @@ func TestCheck_Failure(t *testing.T) {
 		t.Errorf("Password: %v, Should fail with: %+v", pwd, rules)
 	}
 }
+
+func TestEvaluate_Violations(t *testing.T) {
+	rules := defaultStrength()
+
+	report := Evaluate(rules, "OneTwo3!")
+	if !report.Passed || len(report.Violations) != 0 {
+		t.Errorf("Evaluate should pass: %+v", report)
+	}
+
+	report = Evaluate(rules, "one")
+	expected := []Violation{
+		{RuleMinUpperCase, 2, 0},
+		{RuleMinDigits, 1, 0},
+		{RuleMinSpecial, 1, 0},
+		{RuleMinLength, 8, 3},
+	}
+	if report.Passed || !reflect.DeepEqual(report.Violations, expected) {
+		t.Errorf("Evaluate should return %+v, not %+v", expected, report.Violations)
+	}
+
+	report = Evaluate(rules, "ONETWo3!XXXX")
+	expected = []Violation{
+		{RuleMinLowerCase, 2, 1},
+		{RuleMaxLength, 10, 12},
+	}
+	if report.Passed || !reflect.DeepEqual(report.Violations, expected) {
+		t.Errorf("Evaluate should return %+v, not %+v", expected, report.Violations)
+	}
+}
+
+func TestEvaluate_SpecialCharacters(t *testing.T) {
+	rules := PasswordStrength{MinSpecial: 1, MaxLength: 50}
+
+	if Check(rules, "password?") {
+		t.Errorf("Check should not count ? as special by default")
+	}
+
+	rules.SpecialCharacters = "?."
+	if !Check(rules, "password?") || Check(rules, "password!") {
+		t.Errorf("Check should only count the configured special characters")
+	}
+
+	rules.SpecialCharacters = ""
+	rules.SpecialCategories = []string{"P", "S"}
+	for _, pwd := range []string{"password?", "password.", "password¿", "password€", "password!"} {
+		if !Check(rules, pwd) {
+			t.Errorf("Check should count the special character in %q", pwd)
+		}
+	}
+
+	rules.SpecialCategories = []string{"Unknown"}
+	if Check(rules, "password?") {
+		t.Errorf("Check should ignore unknown categories")
+	}
+}
+
+func TestEvaluate_Normalization(t *testing.T) {
+	rules := PasswordStrength{MinDigits: 2, MinUpperCase: 1, MaxLength: 50}
+
+	// Full width letters and digits are normalized to ASCII
+	report := Evaluate(rules, "Ｐａｓｓ１２")
+	if !report.Passed {
+		t.Errorf("Evaluate should count the normalized characters: %+v", report)
+	}
+
+	// The ligature ﬃ is normalized to 3 letters
+	rules = PasswordStrength{MinLength: 3, MaxLength: 50}
+	if !Check(rules, "ﬃ") {
+		t.Errorf("Check should count the length of the normalized password")
+	}
+}
+
+func TestEvaluate_Forbidden(t *testing.T) {
+	rules := PasswordStrength{MaxLength: 50, ForbidControl: true, ForbidWhitespace: true}
+
+	report := Evaluate(rules, "pass\tword one")
+	expected := []Violation{
+		{RuleControl, 0, 1},
+		{RuleWhitespace, 0, 2},
+	}
+	if !reflect.DeepEqual(report.Violations, expected) {
+		t.Errorf("Evaluate should return %+v, not %+v", expected, report.Violations)
+	}
+
+	rules = PasswordStrength{MaxLength: 50}
+	if !Check(rules, "pass\tword one") {
+		t.Errorf("Check should allow control and whitespace characters by default")
+	}
+}
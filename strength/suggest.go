@@ -0,0 +1,75 @@
+package strength
+
+import "fmt"
+
+// Suggest Returns the advice for a user to pick a stronger password. The
+// advice follows the rules the password does not pass and the patterns
+// found by the estimate. Nothing is suggested for a very strong password
+// that passes every rule.
+func Suggest(report Report) []string {
+	var suggestions []string
+	add := func(suggestion string) {
+		for _, s := range suggestions {
+			if s == suggestion {
+				return
+			}
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	for _, violation := range report.Violations {
+		switch violation.Rule {
+		case RuleMinLowerCase:
+			add("Add at least " + count(violation.Required, "lower case letter"))
+		case RuleMinUpperCase:
+			add("Add at least " + count(violation.Required, "upper case letter"))
+		case RuleMinDigits:
+			add("Add at least " + count(violation.Required, "digit"))
+		case RuleMinSpecial:
+			add("Add at least " + count(violation.Required, "special character"))
+		case RuleMinLength:
+			add("Use at least " + count(violation.Required, "character"))
+		case RuleMaxLength:
+			add("Use at most " + count(violation.Required, "character"))
+		case RuleMinScore:
+			add("Add a few more words or characters that are hard to guess")
+		case RuleBreached:
+			add("This password was found in data breaches. Pick a different password")
+		case RuleContext:
+			add("Avoid your name, username, or email in the password")
+		case RuleControl:
+			add("Remove the control characters")
+		case RuleWhitespace:
+			add("Remove the spaces")
+		}
+	}
+
+	if report.Estimate == nil || report.Estimate.Score >= uint(len(scoreThresholds)) {
+		return suggestions
+	}
+
+	for _, pattern := range report.Estimate.Patterns {
+		switch pattern {
+		case patternDictionary:
+			add("Avoid common words and passwords even with substitutions like @ for a")
+		case patternSpatial:
+			add("Avoid keyboard patterns like qwerty or asdf")
+		case patternRepeat:
+			add("Avoid repeated characters and words")
+		case patternSequence:
+			add("Avoid sequences like abc or 123")
+		case patternDate:
+			add("Avoid dates and years")
+		}
+	}
+
+	return suggestions
+}
+
+// count Returns the count followed by the noun in singular or plural.
+func count(n uint, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%v %v", n, noun)
+	}
+	return fmt.Sprintf("%v %vs", n, noun)
+}
@@ -0,0 +1,25 @@
+package strength
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	rules := PasswordStrength{MinDigits: 1, MinLength: 8, MaxLength: 50, MinScore: 3}
+
+	suggestions := Suggest(Evaluate(rules, "dfghjkl;"))
+	expected := []string{
+		"Add at least 1 digit",
+		"Add a few more words or characters that are hard to guess",
+		"Avoid keyboard patterns like qwerty or asdf",
+	}
+	if !reflect.DeepEqual(suggestions, expected) {
+		t.Errorf("Suggest should return %q, not %q", expected, suggestions)
+	}
+
+	suggestions = Suggest(Evaluate(rules, "correct horse battery staple 1"))
+	if len(suggestions) != 0 {
+		t.Errorf("Suggest should not return suggestions for a strong password: %q", suggestions)
+	}
+}
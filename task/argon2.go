@@ -0,0 +1,176 @@
+package task
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func newArgon2Hasher(conf config.Config) Hasher {
+	h := argon2Hasher{
+		memory:     conf.Argon2.Memory,
+		time:       conf.Argon2.Iterations,
+		threads:    conf.Argon2.Parallelism,
+		saltLength: conf.Argon2.SaltLength,
+		keyLength:  conf.Argon2.KeyLength,
+	}
+
+	// Use the recommended parameters when they are not configured
+	if h.memory == 0 {
+		h.memory = 19 * 1024
+	}
+	if h.time == 0 {
+		h.time = 2
+	}
+	if h.threads == 0 {
+		h.threads = 1
+	}
+	if h.saltLength == 0 {
+		h.saltLength = defaultSaltLength
+	}
+	if h.keyLength == 0 {
+		h.keyLength = defaultKeyLength
+	}
+
+	return h
+}
+
+// argon2Hasher Hashes passwords with Argon2id using a random salt per hash.
+// The hash is encoded using the PHC string format. For example:
+// $argon2id$v=19$m=19456,t=2,p=1$salt$hash
+type argon2Hasher struct {
+	memory     uint32
+	time       uint32
+	threads    uint8
+	saltLength uint32
+	keyLength  uint32
+}
+
+func (h argon2Hasher) Name() string {
+	return Argon2id
+}
+
+func (h argon2Hasher) Hash(password []byte) (string, error) {
+	salt, err := newSalt(int(h.saltLength))
+	if err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey(password, salt, h.time, h.memory, h.threads, h.keyLength)
+
+	params := fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2.Version, h.memory, h.time, h.threads)
+	return encodePHC(Argon2id, params, salt, hash), nil
+}
+
+func (h argon2Hasher) Verify(password []byte, encoded string) (bool, error) {
+	params, phc, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	hash := argon2.IDKey(password, phc.Salt, params.time, params.memory, params.threads, params.keyLength)
+
+	return subtle.ConstantTimeCompare(hash, phc.Hash) == 1, nil
+}
+
+func (h argon2Hasher) NeedsRehash(encoded string) (bool, error) {
+	params, _, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+	return params != h, nil
+}
+
+// decode Returns a hasher with the parameters found in the encoded hash.
+func (h argon2Hasher) decode(encoded string) (argon2Hasher, phcHash, error) {
+	params := argon2Hasher{}
+
+	phc, err := decodePHC(encoded)
+	if err != nil {
+		return params, phc, err
+	}
+	if phc.ID != Argon2id || phc.Version != argon2.Version {
+		return params, phc, fmt.Errorf("task: Invalid argon2id hash")
+	}
+
+	memory, err := phc.intParam("m", 32)
+	if err != nil {
+		return params, phc, err
+	}
+	time, err := phc.intParam("t", 32)
+	if err != nil {
+		return params, phc, err
+	}
+	threads, err := phc.intParam("p", 8)
+	if err != nil {
+		return params, phc, err
+	}
+
+	// The parameters come from clients so they must not exhaust the server
+	if memory > costLimit(uint64(h.memory), maxArgon2Memory) ||
+		time > costLimit(uint64(h.time), maxArgon2Iterations) ||
+		threads > costLimit(uint64(h.threads), maxArgon2Parallelism) {
+		return params, phc, fmt.Errorf("task: argon2id parameters exceed the limits")
+	}
+
+	params.memory = uint32(memory)
+	params.time = uint32(time)
+	params.threads = uint8(threads)
+	params.saltLength = uint32(len(phc.Salt))
+	params.keyLength = uint32(len(phc.Hash))
+
+	return params, phc, nil
+}
+
+// Calibrate Scales the memory linearly from a baseline benchmark while
+// keeping the configured iterations and parallelism. The iterations are
+// only increased once the memory reaches its limit.
+func (h argon2Hasher) Calibrate(conf *config.Config, target time.Duration) (Calibration, error) {
+	calibration := Calibration{Algorithm: Argon2id}
+	params := h
+	params.memory = argon2Baseline
+
+	duration, err := benchmark(params)
+	if err != nil {
+		return calibration, err
+	}
+
+	memory := scale(int(params.memory), duration, target, minArgon2Memory)
+	if memory > maxArgon2Memory {
+		duration = time.Duration(float64(duration) * maxArgon2Memory / float64(params.memory))
+		params.time = uint32(scale(int(params.time), duration, target, int(params.time)))
+		memory = maxArgon2Memory
+		if params.time > maxArgon2Iterations {
+			params.time = maxArgon2Iterations
+		}
+	}
+	params.memory = uint32(memory)
+
+	calibration.Duration, err = benchmark(params)
+	if err != nil {
+		return calibration, err
+	}
+
+	conf.Argon2.Memory = params.memory
+	conf.Argon2.Iterations = params.time
+	conf.Argon2.Parallelism = params.threads
+	calibration.Parameters = fmt.Sprintf("m=%d,t=%d,p=%d", params.memory, params.time, params.threads)
+
+	return calibration, nil
+}
+
+const (
+	// The memory is in KiB
+	argon2Baseline  = 8 * 1024
+	minArgon2Memory = 1024
+	maxArgon2Memory = 1024 * 1024
+
+	// maxArgon2Iterations and maxArgon2Parallelism Cap the hashes that
+	// are verified along with maxArgon2Memory whatever the configuration.
+	maxArgon2Iterations  = 32
+	maxArgon2Parallelism = 16
+)
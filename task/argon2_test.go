@@ -0,0 +1,59 @@
+package task
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func TestArgon2_Parameters(t *testing.T) {
+	conf := config.Config{}
+	conf.Argon2.Memory = 8 * 1024
+	conf.Argon2.Iterations = 3
+	conf.Argon2.Parallelism = 2
+	conf.Argon2.SaltLength = 24
+	conf.Argon2.KeyLength = 48
+
+	hasher, err := NewHasher(Argon2id, conf)
+	if err != nil {
+		t.Errorf("NewHasher failed: %v", err)
+		return
+	}
+
+	password := []byte("angryMonkey")
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		t.Errorf("Hash failed: %v", err)
+		return
+	}
+
+	fields := strings.Split(hash, "$")
+	if len(fields) != 6 {
+		t.Errorf("Invalid PHC string: %v", hash)
+		return
+	}
+	if fields[1] != "argon2id" || fields[2] != "v=19" || fields[3] != "m=8192,t=3,p=2" {
+		t.Errorf("Invalid PHC parameters: %v", hash)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil || len(salt) != 24 {
+		t.Errorf("Invalid salt: %v %v", fields[4], err)
+		return
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(key) != 48 {
+		t.Errorf("Invalid key: %v %v", fields[5], err)
+		return
+	}
+
+	expected := argon2.IDKey(password, salt, 3, 8*1024, 2, 48)
+	if string(expected) != string(key) {
+		t.Errorf("Argon2id key does not match")
+	}
+}
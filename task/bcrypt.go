@@ -0,0 +1,111 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func newBcryptHasher(conf config.Config) Hasher {
+	h := bcryptHasher{cost: conf.Bcrypt.Cost}
+	if h.cost == 0 {
+		h.cost = bcrypt.DefaultCost
+	}
+	return h
+}
+
+// bcryptHasher Hashes passwords with bcrypt. The hash is encoded using
+// the modular crypt format. For example: $2a$10$...
+type bcryptHasher struct {
+	cost int
+}
+
+func (h bcryptHasher) Name() string {
+	return Bcrypt
+}
+
+// MaxPasswordLength bcrypt only uses the first 72 bytes of a password and
+// rejects longer ones.
+func (h bcryptHasher) MaxPasswordLength() int {
+	return maxBcryptPassword
+}
+
+func (h bcryptHasher) Hash(password []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h bcryptHasher) Verify(password []byte, encoded string) (bool, error) {
+	_, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), password)
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h bcryptHasher) NeedsRehash(encoded string) (bool, error) {
+	cost, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+	return cost != h.cost, nil
+}
+
+// decode Returns the cost found in the encoded hash. The cost comes from
+// clients so it can only be a little higher than the configured one.
+func (h bcryptHasher) decode(encoded string) (int, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return 0, err
+	}
+	if cost > h.cost+maxBcryptCostMargin {
+		return 0, fmt.Errorf("task: bcrypt cost exceeds the limit")
+	}
+	return cost, nil
+}
+
+// Calibrate Uses the highest cost whose hash does not take longer than
+// the target. Each cost increment doubles the hash duration.
+func (h bcryptHasher) Calibrate(conf *config.Config, target time.Duration) (Calibration, error) {
+	calibration := Calibration{Algorithm: Bcrypt}
+	cost := bcrypt.MinCost
+
+	for ; cost <= bcrypt.MaxCost; cost++ {
+		duration, err := benchmark(bcryptHasher{cost: cost})
+		if err != nil {
+			return calibration, err
+		}
+		if duration > target && cost > bcrypt.MinCost {
+			break
+		}
+		calibration.Duration = duration
+	}
+
+	conf.Bcrypt.Cost = cost - 1
+	calibration.Parameters = fmt.Sprintf("cost=%d", conf.Bcrypt.Cost)
+
+	return calibration, nil
+}
+
+const (
+	// maxBcryptPassword The longest password in bytes bcrypt accepts.
+	maxBcryptPassword = 72
+	// maxBcryptCostMargin How much higher than the configured cost the
+	// cost of a verified hash can be. Each increment doubles the work.
+	maxBcryptCostMargin = 2
+)
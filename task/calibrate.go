@@ -0,0 +1,97 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+// Calibrator Implemented by hashers whose cost can be tuned. Hashers
+// that do not implement this interface are skipped by Calibrate.
+type Calibrator interface {
+	// Calibrate Benchmarks the hasher and updates the configuration with the
+	// parameters that make a single hash take about the target duration.
+	Calibrate(conf *config.Config, target time.Duration) (Calibration, error)
+}
+
+// Calibration The parameters proposed for a hash algorithm.
+type Calibration struct {
+	// Algorithm The name of the hash algorithm.
+	Algorithm string
+	// Parameters The proposed parameters. For example: "cost=12".
+	Parameters string
+	// Duration The measured duration of a single hash using the proposed parameters.
+	Duration time.Duration
+}
+
+// Calibrate Benchmarks every registered hasher on the local CPU and proposes
+// the parameters that make a single hash take about the target duration.
+// The returned configuration is a copy of conf with the proposed parameters.
+func Calibrate(conf config.Config, target time.Duration) (config.Config, []Calibration, error) {
+	var calibrations []Calibration
+
+	if target <= 0 {
+		return conf, nil, fmt.Errorf("task: Invalid calibration target %v", target)
+	}
+
+	for _, name := range HasherNames() {
+		hasher, err := newHasher(name, conf)
+		if err != nil {
+			return conf, nil, err
+		}
+
+		calibrator, ok := hasher.(Calibrator)
+		if !ok {
+			continue
+		}
+
+		calibration, err := calibrator.Calibrate(&conf, target)
+		if err != nil {
+			return conf, nil, fmt.Errorf("task: Failed to calibrate %v: %v", name, err)
+		}
+		calibrations = append(calibrations, calibration)
+	}
+
+	return conf, calibrations, nil
+}
+
+// benchmark Returns the fastest of a few hashes. The fastest run is used
+// because it is the least affected by other processes.
+func benchmark(hasher Hasher) (time.Duration, error) {
+	var fastest time.Duration
+
+	for i := 0; i < calibrationRuns; i++ {
+		start := time.Now()
+		_, err := hasher.Hash([]byte(calibrationPassword))
+		if err != nil {
+			return 0, err
+		}
+
+		duration := time.Since(start)
+		if i == 0 || duration < fastest {
+			fastest = duration
+		}
+	}
+
+	return fastest, nil
+}
+
+// scale Returns value scaled by the ratio between the target and measured
+// durations. The result is never lower than min.
+func scale(value int, measured, target time.Duration, min int) int {
+	if measured <= 0 {
+		measured = time.Nanosecond
+	}
+
+	scaled := int(float64(value) * float64(target) / float64(measured))
+	if scaled < min {
+		return min
+	}
+	return scaled
+}
+
+const (
+	calibrationRuns     = 3
+	calibrationPassword = "calibration password"
+)
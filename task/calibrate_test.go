@@ -0,0 +1,57 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func TestCalibrate(t *testing.T) {
+	conf := config.Config{}
+	target := 20 * time.Millisecond
+
+	calibrated, calibrations, err := Calibrate(conf, target)
+	if err != nil {
+		t.Errorf("Calibrate failed: %v", err)
+		return
+	}
+
+	// Every registered algorithm except sha512 can be calibrated
+	if len(calibrations) != len(HasherNames())-1 {
+		t.Errorf("Invalid number of calibrations: %+v", calibrations)
+	}
+
+	for _, calibration := range calibrations {
+		if calibration.Parameters == "" || calibration.Duration <= 0 {
+			t.Errorf("Invalid calibration: %+v", calibration)
+		}
+	}
+
+	if calibrated.Bcrypt.Cost == 0 || calibrated.Scrypt.LogN == 0 ||
+		calibrated.PBKDF2.Iterations == 0 || calibrated.Argon2.Memory == 0 {
+		t.Errorf("Calibrate should set all the parameters: %+v", calibrated)
+	}
+
+	// The calibrated parameters are used by the hashers
+	hasher, _ := NewHasher(Bcrypt, calibrated)
+	if hasher.(bcryptHasher).cost != calibrated.Bcrypt.Cost {
+		t.Errorf("Hasher should use the calibrated cost")
+	}
+}
+
+func TestCalibrate_InvalidTarget(t *testing.T) {
+	_, _, err := Calibrate(config.Config{}, 0)
+	if err == nil {
+		t.Errorf("Calibrate should fail with an invalid target")
+	}
+}
+
+func TestScale(t *testing.T) {
+	if scale(1000, time.Second, 2*time.Second, 1) != 2000 {
+		t.Errorf("scale should double the value")
+	}
+	if scale(1000, time.Second, time.Millisecond, 100) != 100 {
+		t.Errorf("scale should not go below the minimum")
+	}
+}
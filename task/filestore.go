@@ -0,0 +1,469 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// OpenFileStore Opens the file-backed store in the directory. The directory
+// is created if it does not exist. The tasks are kept in memory and every
+// change is appended to a write-ahead log before it is acknowledged. The log
+// is compacted into a snapshot after every 1000 changes. The log keeps the
+// passwords of the tasks that are not done encrypted with the key in keyFile
+// so they can be hashed after a restart. The key file is created with a
+// random key if it does not exist and must be kept out of the directory. If
+// keyFile is empty, the passwords are never stored and the tasks that are
+// not done fail after a restart.
+func OpenFileStore(dir, keyFile string) (TaskStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &fileStore{dir: dir, tasks: make(map[string]Task)}
+
+	if keyFile != "" {
+		s.aead, err = openStoreKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	s.recovered, err = s.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.compact()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+type fileStore struct {
+	mutex     sync.Mutex
+	dir       string
+	tasks     map[string]Task
+	wal       *os.File
+	records   int
+	recovered map[string][]byte
+	aead      cipher.AEAD
+}
+
+// walRecord A change appended to the write-ahead log. The password of
+// an accept record is encrypted and prefixed with its nonce.
+type walRecord struct {
+	Op       string
+	Task     Task
+	Password []byte `json:",omitempty"`
+}
+
+func (s *fileStore) Accept(task Task, password []byte) error {
+	record := walRecord{Op: opAccept, Task: task}
+	if s.aead != nil {
+		sealed, err := s.seal(task.ID, password)
+		if err != nil {
+			return fmt.Errorf("task: Failed to encrypt password: %v", err)
+		}
+		record.Password = sealed
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.append(record)
+	if err != nil {
+		return err
+	}
+
+	s.tasks[task.ID] = task
+	return s.compactIfNeeded()
+}
+
+func (s *fileStore) Update(task Task) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.append(walRecord{Op: opUpdate, Task: task})
+	if err != nil {
+		return err
+	}
+
+	s.tasks[task.ID] = task
+	return s.compactIfNeeded()
+}
+
+func (s *fileStore) Get(id string) (Task, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	task, exists := s.tasks[id]
+	return task, exists
+}
+
+func (s *fileStore) Delete(ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	records := make([]walRecord, len(ids))
+	for i, id := range ids {
+		records[i] = walRecord{Op: opDelete, Task: Task{ID: id}}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.append(records...)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		delete(s.tasks, id)
+	}
+	return s.compactIfNeeded()
+}
+
+// Recover Returns the passwords found in the log only once since the
+// manager takes ownership of them.
+func (s *fileStore) Recover() ([]Task, map[string][]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+
+	passwords := s.recovered
+	s.recovered = make(map[string][]byte)
+
+	return tasks, passwords, nil
+}
+
+func (s *fileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.wal.Close()
+}
+
+// append Appends the records to the log with a single write and syncs
+// them to disk. The caller must hold the mutex.
+func (s *fileStore) append(records ...walRecord) error {
+	var lines bytes.Buffer
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		lines.Write(line)
+		lines.WriteByte('\n')
+	}
+
+	_, err := s.wal.Write(lines.Bytes())
+	if err != nil {
+		return fmt.Errorf("task: Failed to write log: %v", err)
+	}
+
+	err = s.wal.Sync()
+	if err != nil {
+		return fmt.Errorf("task: Failed to sync log: %v", err)
+	}
+
+	s.records += len(records)
+	return nil
+}
+
+// compactIfNeeded Compacts the log once enough records have been appended.
+// The caller must hold the mutex and have applied the appended records.
+func (s *fileStore) compactIfNeeded() error {
+	if s.records < snapshotRecords {
+		return nil
+	}
+	return s.compact()
+}
+
+func (s *fileStore) loadSnapshot() error {
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, snapshotFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var tasks []Task
+	err = json.Unmarshal(content, &tasks)
+	if err != nil {
+		return fmt.Errorf("task: Invalid snapshot: %v", err)
+	}
+
+	for _, task := range tasks {
+		s.tasks[task.ID] = task
+	}
+
+	return nil
+}
+
+// replay Applies the log records on top of the snapshot. The passwords of
+// the tasks that are not done are returned. A partially written last record
+// is ignored since it was never acknowledged. The passwords that cannot be
+// decrypted with the key are ignored so their tasks fail.
+func (s *fileStore) replay() (map[string][]byte, error) {
+	passwords := make(map[string][]byte)
+
+	lines, err := s.readLog()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, line := range lines {
+		record := walRecord{}
+		err = json.Unmarshal(line, &record)
+		if err != nil && i == len(lines)-1 {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("task: Invalid log record %v: %v", i+1, err)
+		}
+
+		switch record.Op {
+		case opAccept:
+			s.tasks[record.Task.ID] = record.Task
+			if password, valid := s.open(record); valid {
+				passwords[record.Task.ID] = password
+			}
+		case opUpdate:
+			s.tasks[record.Task.ID] = record.Task
+		case opDelete:
+			delete(s.tasks, record.Task.ID)
+		}
+	}
+
+	for id := range passwords {
+		if task, exists := s.tasks[id]; !exists || task.Done {
+			delete(passwords, id)
+		}
+	}
+
+	return passwords, nil
+}
+
+// readLog Returns the non-empty lines of the log.
+func (s *fileStore) readLog() ([][]byte, error) {
+	file, err := os.Open(filepath.Join(s.dir, walFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// A bufio.Reader is used since a record has no size limit
+	var lines [][]byte
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// compact Writes a snapshot of all the tasks and replaces the log with
+// the accept records of the tasks that are not done. The files are
+// replaced atomically so a crash leaves either the old or the new files.
+// The caller must hold the mutex.
+func (s *fileStore) compact() error {
+	lines, err := s.readLog()
+	if err != nil {
+		return err
+	}
+
+	var pending bytes.Buffer
+	for _, line := range lines {
+		record := walRecord{}
+		if json.Unmarshal(line, &record) != nil || record.Op != opAccept {
+			continue
+		}
+		if task, exists := s.tasks[record.Task.ID]; !exists || task.Done {
+			continue
+		}
+
+		// The passwords that cannot be decrypted with the key, such as
+		// the ones written without a key, are not carried over
+		if password, valid := s.open(record); valid {
+			zero(password)
+		} else if record.Password != nil {
+			record.Password = nil
+			line, err = json.Marshal(record)
+			if err != nil {
+				return err
+			}
+		}
+
+		pending.Write(line)
+		pending.WriteByte('\n')
+	}
+
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, task)
+	}
+
+	snapshot, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(filepath.Join(s.dir, snapshotFile), snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(filepath.Join(s.dir, walFile), pending.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if s.wal != nil {
+		s.wal.Close()
+	}
+
+	s.wal, err = os.OpenFile(filepath.Join(s.dir, walFile), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	s.records = 0
+	return nil
+}
+
+// seal Encrypts the password of the task. The task ID is authenticated
+// so the password cannot be moved to another task.
+func (s *fileStore) seal(id string, password []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, password, []byte(id)), nil
+}
+
+// open Decrypts the password of an accept record. This call returns
+// false if the record has no password the key can decrypt.
+func (s *fileStore) open(record walRecord) ([]byte, bool) {
+	if s.aead == nil || len(record.Password) < s.aead.NonceSize() {
+		return nil, false
+	}
+
+	nonce, sealed := record.Password[:s.aead.NonceSize()], record.Password[s.aead.NonceSize():]
+	password, err := s.aead.Open(nil, nonce, sealed, []byte(record.Task.ID))
+	return password, err == nil
+}
+
+// openStoreKey Returns the cipher that encrypts the passwords kept in
+// the log. The key file is created with a random key if it does not exist.
+func openStoreKey(keyFile string) (cipher.AEAD, error) {
+	key, err := readStoreKey(keyFile)
+	if os.IsNotExist(err) {
+		key = make([]byte, storeKeyLength)
+		_, err = rand.Read(key)
+		if err == nil {
+			err = writeFileAtomic(keyFile, []byte(base64.StdEncoding.EncodeToString(key)))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("task: Failed to load store key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readStoreKey Reads the base-64 encoded key of the store.
+func readStoreKey(keyFile string) ([]byte, error) {
+	content, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(content)))
+	if err != nil || len(key) != storeKeyLength {
+		return nil, fmt.Errorf("task: Invalid store key in %v", keyFile)
+	}
+
+	return key, nil
+}
+
+// writeFileAtomic Writes the data to a temporary file and renames
+// it to filePath once it has been synced to disk.
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp := filePath + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, filePath)
+}
+
+const (
+	opAccept = "accept"
+	opUpdate = "update"
+	opDelete = "delete"
+
+	walFile      = "tasks.wal"
+	snapshotFile = "tasks.snapshot"
+
+	// snapshotRecords The number of records appended to
+	// the log before it is compacted into a snapshot.
+	snapshotRecords = 1000
+
+	// storeKeyLength The length of the AES-256 key
+	// that encrypts the passwords in the log.
+	storeKeyLength = 32
+)
@@ -0,0 +1,313 @@
+package task
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func TestFileStore_Recover(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(t.TempDir(), "store.key")
+
+	store, err := OpenFileStore(dir, key)
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+
+	store.Accept(Task{ID: "1", Algorithm: SHA512}, []byte("pass1"))
+	store.Accept(Task{ID: "2", Algorithm: SHA512}, []byte("pass2"))
+	store.Accept(Task{ID: "3", Algorithm: SHA512}, []byte("pass3"))
+	store.Accept(Task{ID: "4", Algorithm: SHA512}, []byte("pass4"))
+	store.Update(Task{ID: "1", Algorithm: SHA512, Done: true, Hash: "hash1"})
+	store.Delete("3", "4")
+	store.Delete()
+	store.Close()
+
+	store, err = OpenFileStore(dir, key)
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	defer store.Close()
+
+	tasks, passwords, err := store.Recover()
+	if err != nil {
+		t.Errorf("Recover failed: %v", err)
+		return
+	}
+
+	if len(tasks) != 2 {
+		t.Errorf("Recover should return 2 tasks, not %v", len(tasks))
+	}
+
+	task, exists := store.Get("1")
+	if !exists || !task.Done || task.Hash != "hash1" {
+		t.Errorf("Task 1 should be done: %+v", task)
+	}
+
+	if len(passwords) != 1 || string(passwords["2"]) != "pass2" {
+		t.Errorf("Recover should only return the password of task 2: %q", passwords)
+	}
+
+	// The passwords are only returned once
+	_, passwords, _ = store.Recover()
+	if len(passwords) != 0 {
+		t.Errorf("Recover should not return the passwords twice: %q", passwords)
+	}
+}
+
+func TestFileStore_EncryptedPasswords(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(t.TempDir(), "store.key")
+
+	store, err := OpenFileStore(dir, key)
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	store.Accept(Task{ID: "1"}, []byte("angryMonkey"))
+	store.Close()
+
+	log, err := ioutil.ReadFile(filepath.Join(dir, walFile))
+	if err != nil {
+		t.Errorf("Failed to read log: %v", err)
+		return
+	}
+	plaintext := base64.StdEncoding.EncodeToString([]byte("angryMonkey"))
+	if bytes.Contains(log, []byte("angryMonkey")) || bytes.Contains(log, []byte(plaintext)) {
+		t.Errorf("The log should not contain the plaintext password: %s", log)
+	}
+
+	// The password cannot be decrypted with another key
+	store, err = OpenFileStore(dir, filepath.Join(t.TempDir(), "store.key"))
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	tasks, passwords, _ := store.Recover()
+	store.Close()
+	if len(tasks) != 1 || len(passwords) != 0 {
+		t.Errorf("Recover should not return the password with another key: %q", passwords)
+	}
+
+	// Without a key, no password is written and the compacted log drops
+	// the passwords it cannot decrypt
+	store, err = OpenFileStore(dir, "")
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	store.Accept(Task{ID: "2"}, []byte("angryMonkey"))
+	store.Close()
+
+	log, _ = ioutil.ReadFile(filepath.Join(dir, walFile))
+	if bytes.Contains(log, []byte("Password")) {
+		t.Errorf("The log should not contain any password without a key: %s", log)
+	}
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(t.TempDir(), "store.key")
+
+	store, err := OpenFileStore(dir, key)
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	defer store.Close()
+
+	for i := 1; i <= snapshotRecords; i++ {
+		id := strconv.Itoa(i)
+		store.Accept(Task{ID: id}, []byte("password"))
+		store.Update(Task{ID: id, Done: true})
+	}
+	store.Accept(Task{ID: "pending"}, []byte("pending"))
+
+	log, err := ioutil.ReadFile(filepath.Join(dir, walFile))
+	if err != nil {
+		t.Errorf("Failed to read log: %v", err)
+		return
+	}
+
+	// Only the accept record of the pending task should be left
+	lines := bytes.Split(bytes.TrimSpace(log), []byte("\n"))
+	if len(lines) != 1 {
+		t.Errorf("Log should have 1 record, not %v", len(lines))
+	}
+
+	info, err := os.Stat(filepath.Join(dir, snapshotFile))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Snapshot should be private: %v", err)
+	}
+}
+
+func TestFileStore_TruncatedLog(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(t.TempDir(), "store.key")
+
+	store, err := OpenFileStore(dir, key)
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	store.Accept(Task{ID: "1"}, []byte("pass1"))
+	store.Close()
+
+	file, err := os.OpenFile(filepath.Join(dir, walFile), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Errorf("Failed to open log: %v", err)
+		return
+	}
+	file.WriteString(`{"Op":"accept","Task":{"ID":2`)
+	file.Close()
+
+	store, err = OpenFileStore(dir, key)
+	if err != nil {
+		t.Errorf("OpenFileStore should ignore a partial record: %v", err)
+		return
+	}
+	defer store.Close()
+
+	_, exists := store.Get("2")
+	if exists {
+		t.Errorf("Task 2 should not be recovered")
+	}
+}
+
+func TestManager_Restart(t *testing.T) {
+	conf := config.Config{}
+	conf.DataDirectory = t.TempDir()
+
+	mgr, err := OpenManager(conf)
+	if err != nil {
+		t.Errorf("OpenManager failed: %v", err)
+		return
+	}
+
+	mgr.NewTask(Request{Password: []byte("angryMonkey")})
+	mgr.WaitForPendingTasks()
+	before := mgr.Check("1")
+	mgr.Close()
+
+	mgr, err = OpenManager(conf)
+	if err != nil {
+		t.Errorf("OpenManager failed: %v", err)
+		return
+	}
+	defer mgr.Close()
+
+	after := mgr.Check("1")
+	if after.Code != 200 || after.Message != before.Message {
+		t.Errorf("Check should return the same hash after a restart: %+v %+v", before, after)
+	}
+
+	res := mgr.NewTask(Request{Password: []byte("angryMonkey")})
+	if res.Message != "2" {
+		t.Errorf("NewTask should return ID 2, not %v", res.Message)
+	}
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_RestartLongPassword(t *testing.T) {
+	conf := config.Config{}
+	conf.DataDirectory = t.TempDir()
+
+	mgr, err := OpenManager(conf)
+	if err != nil {
+		t.Errorf("OpenManager failed: %v", err)
+		return
+	}
+
+	// The log record is larger than the default bufio.Scanner buffer
+	password := bytes.Repeat([]byte("a"), 100000)
+	res := mgr.NewTask(Request{Password: append([]byte(nil), password...)})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+	mgr.WaitForPendingTasks()
+	mgr.Close()
+
+	mgr, err = OpenManager(conf)
+	if err != nil {
+		t.Errorf("OpenManager should read the long log record: %v", err)
+		return
+	}
+	defer mgr.Close()
+
+	res = mgr.Check("1")
+	verification, _ := mgr.Verify(password, res.Message, false)
+	if res.Code != 200 || !verification.Match {
+		t.Errorf("Check should return the hash of the long password: %+v", res)
+	}
+}
+
+func TestManager_ResumePendingTasks(t *testing.T) {
+	conf := config.Config{}
+	conf.DataDirectory = t.TempDir()
+	conf.DataKeyFile = filepath.Join(t.TempDir(), "store.key")
+
+	// Simulate a crash after the task was accepted
+	store, err := OpenFileStore(conf.DataDirectory, conf.DataKeyFile)
+	if err != nil {
+		t.Errorf("OpenFileStore failed: %v", err)
+		return
+	}
+	store.Accept(Task{ID: "1", Algorithm: SHA512, Created: time.Now()}, []byte("angryMonkey"))
+	store.Close()
+
+	mgr, err := OpenManager(conf)
+	if err != nil {
+		t.Errorf("OpenManager failed: %v", err)
+		return
+	}
+	defer mgr.Close()
+
+	mgr.WaitForPendingTasks()
+
+	res := mgr.Check("1")
+	if res.Code != 200 {
+		t.Errorf("Check should return 200, not %v", res.Code)
+	}
+
+	verification, res := mgr.Verify([]byte("angryMonkey"), res.Message, false)
+	if !verification.Match {
+		t.Errorf("The resumed task should hash the stored password: %+v", res)
+	}
+}
+
+func TestManager_EvictStoredTasks(t *testing.T) {
+	conf := config.Config{}
+	conf.DataDirectory = t.TempDir()
+	conf.MaxCompletedTasks = 1
+
+	mgr, err := OpenManager(conf)
+	if err != nil {
+		t.Errorf("OpenManager failed: %v", err)
+		return
+	}
+	defer mgr.Close()
+
+	mgr.NewTask(Request{Password: []byte("pass1")})
+	mgr.WaitForPendingTasks()
+	mgr.NewTask(Request{Password: []byte("pass2")})
+	mgr.WaitForPendingTasks()
+
+	_, exists := mgr.store.Get("1")
+	if exists {
+		t.Errorf("The evicted task should be deleted from the store")
+	}
+	_, exists = mgr.store.Get("2")
+	if !exists {
+		t.Errorf("The completed task should be kept in the store")
+	}
+}
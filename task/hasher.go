@@ -0,0 +1,336 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+const (
+	// SHA512 The name of the unsalted base-64 encoded SHA512 hasher.
+	SHA512 = "sha512"
+	// Bcrypt The name of the bcrypt hasher.
+	Bcrypt = "bcrypt"
+	// Scrypt The name of the scrypt hasher.
+	Scrypt = "scrypt"
+	// PBKDF2SHA256 The name of the PBKDF2 hasher using HMAC-SHA256.
+	PBKDF2SHA256 = "pbkdf2-sha256"
+	// Argon2id The name of the Argon2id hasher.
+	Argon2id = "argon2id"
+)
+
+// Hasher Represents a password hashing algorithm.
+type Hasher interface {
+	// Name The name used to register the hasher. For example: "bcrypt".
+	Name() string
+	// Hash Hashes the password and returns its encoded representation.
+	Hash(password []byte) (string, error)
+	// Verify Returns true if the password matches the encoded hash. The
+	// parameters found in the encoded hash are used instead of the configured
+	// ones. The comparison is done in constant time.
+	Verify(password []byte, encoded string) (bool, error)
+	// NeedsRehash Returns true if the parameters found in the encoded hash
+	// do not match the parameters this hasher was created with.
+	NeedsRehash(encoded string) (bool, error)
+}
+
+// passwordLimiter Implemented by the hashers that cannot hash passwords
+// longer than a number of bytes.
+type passwordLimiter interface {
+	// MaxPasswordLength The longest password in bytes the hasher accepts.
+	MaxPasswordLength() int
+}
+
+// ErrUnknownAlgorithm Returned when no hasher has been registered
+// for the requested algorithm.
+var ErrUnknownAlgorithm = errors.New("task: Unknown hash algorithm")
+
+// ErrPepperNotConfigured Returned when a peppered hash is given but no
+// pepper file is configured.
+var ErrPepperNotConfigured = errors.New("task: Pepper file is not configured")
+
+// HasherFactory Creates a hasher using the parameters found in the configuration.
+type HasherFactory func(conf config.Config) Hasher
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]HasherFactory
+}{factories: make(map[string]HasherFactory)}
+
+func init() {
+	RegisterHasher(SHA512, newSHA512Hasher)
+	RegisterHasher(Bcrypt, newBcryptHasher)
+	RegisterHasher(Scrypt, newScryptHasher)
+	RegisterHasher(PBKDF2SHA256, newPBKDF2Hasher)
+	RegisterHasher(Argon2id, newArgon2Hasher)
+}
+
+// RegisterHasher Registers a hasher factory under the given name. Registering
+// a name twice replaces the previous factory.
+func RegisterHasher(name string, factory HasherFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.factories[name] = factory
+}
+
+// NewHasher Creates the hasher registered under name. The default algorithm
+// is used if name is empty. If a pepper file is configured, the hasher
+// peppers passwords with the current key. This function returns an error
+// if no hasher has been registered under name or the pepper cannot be loaded.
+func NewHasher(name string, conf config.Config) (Hasher, error) {
+	hasher, err := newHasher(name, conf)
+	if err != nil || conf.PepperFile == "" {
+		return hasher, err
+	}
+
+	ring, err := loadKeyRing(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return pepperHasher{inner: hasher, ring: ring}, nil
+}
+
+func newHasher(name string, conf config.Config) (Hasher, error) {
+	if name == "" {
+		name = DefaultAlgorithm(conf)
+	}
+
+	registry.RLock()
+	factory, exists := registry.factories[name]
+	registry.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w %v", ErrUnknownAlgorithm, name)
+	}
+
+	return factory(conf), nil
+}
+
+// HasherNames Returns the sorted names of all the registered hashers.
+func HasherNames() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.factories))
+	for name := range registry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IdentifyHasher Creates the hasher able to verify the encoded hash. The
+// algorithm is detected from the PHC or modular crypt prefix. Hashes without
+// a prefix are assumed to be base-64 encoded SHA512 hashes. Peppered hashes
+// require the pepper file to be configured.
+func IdentifyHasher(encoded string, conf config.Config) (Hasher, error) {
+	if !isPeppered(encoded) {
+		name := algorithmOf(encoded)
+		if name == "" {
+			return nil, fmt.Errorf("%w: Unknown hash format", ErrUnknownAlgorithm)
+		}
+		return newHasher(name, conf)
+	}
+
+	if conf.PepperFile == "" {
+		return nil, ErrPepperNotConfigured
+	}
+
+	_, inner, err := decodePepper(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Unknown hash format", ErrUnknownAlgorithm)
+	}
+
+	name := algorithmOf(inner)
+	if name == "" {
+		return nil, fmt.Errorf("%w: Unknown hash format", ErrUnknownAlgorithm)
+	}
+
+	return NewHasher(name, conf)
+}
+
+// NeedsRehash Returns true if the encoded hash was not created with the
+// configured algorithm, parameters, and current pepper key. A hash created
+// with an algorithm stronger than the configured one is only compared with
+// the configured parameters of its own algorithm so it is never replaced by
+// a weaker hash. This function returns an error if the encoded hash cannot
+// be decoded.
+func NeedsRehash(conf config.Config, encoded string) (bool, error) {
+	hasher, err := IdentifyHasher(encoded, conf)
+	if err != nil {
+		return false, err
+	}
+
+	outdated, err := hasher.NeedsRehash(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	if conf.PepperFile != "" && !isPeppered(encoded) {
+		return true, nil
+	}
+
+	return outdated || hasher.Name() != rehashAlgorithm(conf, hasher.Name()), nil
+}
+
+// rehashAlgorithm Returns the algorithm that replaces a hash created with
+// the named algorithm. This is the configured algorithm unless it is weaker.
+func rehashAlgorithm(conf config.Config, name string) string {
+	configured := DefaultAlgorithm(conf)
+	if algorithmStrength(configured) < algorithmStrength(name) {
+		return name
+	}
+	return configured
+}
+
+// algorithmStrength Ranks the algorithms so hashes are never replaced by
+// weaker ones. The unsalted SHA512 is the weakest, PBKDF2 is salted, and
+// bcrypt, scrypt, and Argon2id are also memory or cache hard. Registered
+// hashers are ranked like PBKDF2.
+func algorithmStrength(name string) int {
+	switch name {
+	case SHA512:
+		return 0
+	case Bcrypt, Scrypt, Argon2id:
+		return 2
+	}
+	return 1
+}
+
+func algorithmOf(encoded string) string {
+	if !strings.HasPrefix(encoded, "$") {
+		return SHA512
+	}
+
+	id := strings.SplitN(encoded[1:], "$", 2)[0]
+	switch id {
+	case "2a", "2b", "2y":
+		return Bcrypt
+	}
+
+	return id
+}
+
+// DefaultAlgorithm Returns the configured hash algorithm or SHA512 if
+// none has been configured.
+func DefaultAlgorithm(conf config.Config) string {
+	if conf.HashAlgorithm == "" {
+		return SHA512
+	}
+	return conf.HashAlgorithm
+}
+
+func newSalt(length int) ([]byte, error) {
+	salt := make([]byte, length)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, fmt.Errorf("task: Failed to generate salt: %v", err)
+	}
+	return salt, nil
+}
+
+// encodePHC Encodes a hash using the PHC string format:
+// $<id>$<params>$<salt>$<hash>
+func encodePHC(id, params string, salt, hash []byte) string {
+	return fmt.Sprintf("$%s$%s$%s$%s", id, params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash))
+}
+
+// phcHash Represents a hash decoded from the PHC string format.
+type phcHash struct {
+	ID      string
+	Version int
+	Params  map[string]string
+	Salt    []byte
+	Hash    []byte
+}
+
+// decodePHC Decodes a hash from the PHC string format. The version
+// field is optional and is set to zero when it is missing.
+func decodePHC(encoded string) (phcHash, error) {
+	phc := phcHash{Params: make(map[string]string)}
+
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 5 && len(fields) != 6 {
+		return phc, fmt.Errorf("task: Invalid PHC string")
+	}
+
+	phc.ID = fields[1]
+	fields = fields[2:]
+
+	if len(fields) == 4 {
+		version := strings.TrimPrefix(fields[0], "v=")
+		v, err := strconv.Atoi(version)
+		if err != nil || version == fields[0] {
+			return phc, fmt.Errorf("task: Invalid PHC version %v", fields[0])
+		}
+		phc.Version = v
+		fields = fields[1:]
+	}
+
+	for _, param := range strings.Split(fields[0], ",") {
+		pair := strings.SplitN(param, "=", 2)
+		if len(pair) != 2 {
+			return phc, fmt.Errorf("task: Invalid PHC parameter %v", param)
+		}
+		phc.Params[pair[0]] = pair[1]
+	}
+
+	var err error
+	phc.Salt, err = base64.RawStdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return phc, fmt.Errorf("task: Invalid PHC salt: %v", err)
+	}
+
+	phc.Hash, err = base64.RawStdEncoding.DecodeString(fields[2])
+	if err != nil || len(phc.Hash) == 0 {
+		return phc, fmt.Errorf("task: Invalid PHC hash")
+	}
+
+	return phc, nil
+}
+
+// intParam Returns the named PHC parameter as an integer that fits in
+// the given number of bits.
+func (phc phcHash) intParam(name string, bits int) (uint64, error) {
+	value, exists := phc.Params[name]
+	if !exists {
+		return 0, fmt.Errorf("task: Missing PHC parameter %v", name)
+	}
+
+	num, err := strconv.ParseUint(value, 10, bits)
+	if err != nil || num == 0 {
+		return 0, fmt.Errorf("task: Invalid PHC parameter %v=%v", name, value)
+	}
+
+	return num, nil
+}
+
+// costLimit Returns the highest cost parameter accepted from a hash that
+// comes from a client. The parameter can be a few times the configured
+// one but never more than the absolute limit.
+func costLimit(configured, limit uint64) uint64 {
+	if configured > limit/maxCostFactor {
+		return limit
+	}
+	return configured * maxCostFactor
+}
+
+const (
+	defaultSaltLength = 16
+	defaultKeyLength  = 32
+
+	// maxCostFactor How many times the configured work or memory a
+	// verified hash can use. It matches maxBcryptCostMargin.
+	maxCostFactor = 4
+)
@@ -0,0 +1,294 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func TestNewHasher_Default(t *testing.T) {
+	conf := config.Config{}
+
+	hasher, err := NewHasher("", conf)
+	if err != nil {
+		t.Errorf("NewHasher failed: %v", err)
+		return
+	}
+	if hasher.Name() != SHA512 {
+		t.Errorf("Default hasher should be %v, not %v", SHA512, hasher.Name())
+	}
+
+	conf.HashAlgorithm = Scrypt
+	hasher, err = NewHasher("", conf)
+	if err != nil {
+		t.Errorf("NewHasher failed: %v", err)
+		return
+	}
+	if hasher.Name() != Scrypt {
+		t.Errorf("Default hasher should be %v, not %v", Scrypt, hasher.Name())
+	}
+}
+
+func TestNewHasher_Unknown(t *testing.T) {
+	_, err := NewHasher("md5", config.Config{})
+	if err == nil {
+		t.Errorf("NewHasher should fail with an unknown algorithm")
+	}
+}
+
+func TestRegisterHasher(t *testing.T) {
+	RegisterHasher("test", newSHA512Hasher)
+	defer func() {
+		registry.Lock()
+		delete(registry.factories, "test")
+		registry.Unlock()
+	}()
+
+	_, err := NewHasher("test", config.Config{})
+	if err != nil {
+		t.Errorf("NewHasher failed: %v", err)
+	}
+}
+
+func TestHasher_Hash(t *testing.T) {
+	prefixes := map[string]string{
+		SHA512:       "",
+		Bcrypt:       "$2a$",
+		Scrypt:       "$scrypt$ln=15,r=8,p=1$",
+		PBKDF2SHA256: "$pbkdf2-sha256$i=600000$",
+		Argon2id:     "$argon2id$v=19$m=19456,t=2,p=1$",
+	}
+
+	for _, name := range HasherNames() {
+		hasher, err := NewHasher(name, config.Config{})
+		if err != nil {
+			t.Errorf("NewHasher failed: %v", err)
+			continue
+		}
+
+		first, err := hasher.Hash([]byte("angryMonkey"))
+		if err != nil {
+			t.Errorf("%v: Hash failed: %v", name, err)
+			continue
+		}
+		if !strings.HasPrefix(first, prefixes[name]) {
+			t.Errorf("%v: Invalid hash encoding: %v", name, first)
+		}
+
+		second, err := hasher.Hash([]byte("angryMonkey"))
+		if err != nil {
+			t.Errorf("%v: Hash failed: %v", name, err)
+			continue
+		}
+
+		// Only the unsalted SHA512 should produce the same hash twice
+		if (first == second) != (name == SHA512) {
+			t.Errorf("%v: Unexpected salt behavior: %v %v", name, first, second)
+		}
+	}
+}
+
+func TestHasher_SHA512(t *testing.T) {
+	hasher, _ := NewHasher(SHA512, config.Config{})
+	hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+	expected := "ZEHhWB65gUlzdVwtDQArEyx+KVLzp/aTaRaPlBzYRIFj6vjFdqEb0Q5B8zVKCZ0vKbZPZklJz0Fd7su2A+gf7Q=="
+	if hash != expected {
+		t.Errorf("Expected hash %v, got %v", expected, hash)
+	}
+}
+
+func TestHasher_Verify(t *testing.T) {
+	for _, name := range HasherNames() {
+		hasher, _ := NewHasher(name, config.Config{})
+		hash, err := hasher.Hash([]byte("angryMonkey"))
+		if err != nil {
+			t.Errorf("%v: Hash failed: %v", name, err)
+			continue
+		}
+
+		identified, err := IdentifyHasher(hash, config.Config{})
+		if err != nil {
+			t.Errorf("%v: IdentifyHasher failed: %v", name, err)
+			continue
+		}
+		if identified.Name() != name {
+			t.Errorf("%v: IdentifyHasher returned %v", name, identified.Name())
+		}
+
+		match, err := identified.Verify([]byte("angryMonkey"), hash)
+		if err != nil || !match {
+			t.Errorf("%v: Verify should match: %v", name, err)
+		}
+
+		match, err = identified.Verify([]byte("happyMonkey"), hash)
+		if err != nil || match {
+			t.Errorf("%v: Verify should not match: %v", name, err)
+		}
+	}
+}
+
+func TestHasher_VerifyInvalid(t *testing.T) {
+	invalid := map[string]string{
+		SHA512:       "notBase64!",
+		Bcrypt:       "$2a$10$short",
+		Scrypt:       "$scrypt$ln=100,r=8,p=1$c2FsdA$aGFzaA",
+		PBKDF2SHA256: "$pbkdf2-sha256$i=abc$c2FsdA$aGFzaA",
+		Argon2id:     "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+	}
+
+	for name, hash := range invalid {
+		hasher, _ := NewHasher(name, config.Config{})
+		_, err := hasher.Verify([]byte("angryMonkey"), hash)
+		if err == nil {
+			t.Errorf("%v: Verify should fail with %v", name, hash)
+		}
+	}
+}
+
+func TestHasher_HostileParameters(t *testing.T) {
+	hostile := []string{
+		"$argon2id$v=19$m=4294967295,t=4294967295,p=255$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=19456,t=2,p=255$c2FsdA$aGFzaA",
+		"$scrypt$ln=40,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=15,r=1073741823,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=15,r=8,p=1000$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=2147483647$c2FsdA$aGFzaA",
+		"$2a$31$" + strings.Repeat("a", 53),
+
+		// More than four times the default parameters
+		"$argon2id$v=19$m=77825,t=2,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=19456,t=9,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=19456,t=2,p=5$c2FsdA$aGFzaA",
+		"$scrypt$ln=18,r=8,p=1$c2FsdA$aGFzaA",
+		"$scrypt$ln=15,r=8,p=5$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=2400001$c2FsdA$aGFzaA",
+	}
+
+	for _, hash := range hostile {
+		_, err := NeedsRehash(config.Config{}, hash)
+		if err == nil {
+			t.Errorf("NeedsRehash should fail with %v", hash)
+		}
+
+		hasher, err := IdentifyHasher(hash, config.Config{})
+		if err != nil {
+			t.Errorf("IdentifyHasher failed: %v", err)
+			continue
+		}
+		_, err = hasher.Verify([]byte("angryMonkey"), hash)
+		if err == nil {
+			t.Errorf("Verify should fail with %v", hash)
+		}
+	}
+}
+
+func TestHasher_CostLimits(t *testing.T) {
+	// Up to four times the default parameters
+	accepted := []string{
+		"$argon2id$v=19$m=77824,t=8,p=4$c2FsdA$aGFzaA",
+		"$scrypt$ln=17,r=8,p=4$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=2400000$c2FsdA$aGFzaA",
+	}
+
+	for _, hash := range accepted {
+		_, err := NeedsRehash(config.Config{}, hash)
+		if err != nil {
+			t.Errorf("NeedsRehash should accept %v: %v", hash, err)
+		}
+	}
+
+	// The limits follow the configured parameters
+	conf := config.Config{}
+	conf.Argon2.Memory = 64 * 1024
+	_, err := NeedsRehash(conf, "$argon2id$v=19$m=262144,t=2,p=1$c2FsdA$aGFzaA")
+	if err != nil {
+		t.Errorf("NeedsRehash should accept four times the configured memory: %v", err)
+	}
+
+	if costLimit(maxPBKDF2Iterations, maxPBKDF2Iterations) != maxPBKDF2Iterations {
+		t.Errorf("costLimit should never exceed the absolute limit")
+	}
+}
+
+func TestIdentifyHasher_Unknown(t *testing.T) {
+	for _, hash := range []string{"$md5$abc", "$$abc"} {
+		_, err := IdentifyHasher(hash, config.Config{})
+		if err == nil {
+			t.Errorf("IdentifyHasher should fail with %v", hash)
+		}
+	}
+}
+
+func TestDecodePHC(t *testing.T) {
+	phc, err := decodePHC("$argon2id$v=19$m=512,t=3,p=2$c2FsdA$aGFzaA")
+	if err != nil {
+		t.Errorf("decodePHC failed: %v", err)
+		return
+	}
+	if phc.ID != "argon2id" || phc.Version != 19 || phc.Params["m"] != "512" ||
+		string(phc.Salt) != "salt" || string(phc.Hash) != "hash" {
+		t.Errorf("Invalid PHC hash: %+v", phc)
+	}
+
+	invalid := []string{
+		"$argon2id$m=512",
+		"$argon2id$x=19$m=512$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=512$!!$aGFzaA",
+		"$argon2id$v=19$m=512$c2FsdA$",
+	}
+	for _, hash := range invalid {
+		_, err = decodePHC(hash)
+		if err == nil {
+			t.Errorf("decodePHC should fail with %v", hash)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	conf := config.Config{}
+	conf.HashAlgorithm = Argon2id
+
+	for _, name := range HasherNames() {
+		hasher, _ := NewHasher(name, conf)
+		hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+		outdated, err := NeedsRehash(conf, hash)
+		if err != nil {
+			t.Errorf("%v: NeedsRehash failed: %v", name, err)
+			continue
+		}
+		if outdated != (name != Argon2id) {
+			t.Errorf("%v: NeedsRehash returned %v", name, outdated)
+		}
+	}
+
+	// Weaker parameters than the configured ones
+	weak := conf
+	weak.Argon2.Memory = 1024
+	hasher, _ := NewHasher(Argon2id, weak)
+	hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+	outdated, err := NeedsRehash(conf, hash)
+	if err != nil || !outdated {
+		t.Errorf("NeedsRehash should be true: %v", err)
+	}
+
+	// The hashes are never replaced by weaker ones
+	hasher, _ = NewHasher(Argon2id, conf)
+	hash, _ = hasher.Hash([]byte("angryMonkey"))
+	for _, weaker := range []string{"", SHA512, PBKDF2SHA256} {
+		conf := config.Config{HashAlgorithm: weaker}
+		outdated, err := NeedsRehash(conf, hash)
+		if err != nil || outdated {
+			t.Errorf("NeedsRehash should not replace argon2id with %q: %v", weaker, err)
+		}
+	}
+
+	_, err = NeedsRehash(conf, "$argon2id$v=19$m=512")
+	if err == nil {
+		t.Errorf("NeedsRehash should fail with an invalid hash")
+	}
+}
@@ -0,0 +1,172 @@
+package task
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// IDSequential Hands out the IDs 1, 2, 3, and so on. These IDs can be
+	// enumerated so they should only be used when the API is not public.
+	IDSequential = "sequential"
+	// IDRandom Hands out random 128-bit IDs encoded as 32 hex digits.
+	IDRandom = "random"
+	// IDUUIDv7 Hands out time ordered UUIDs as defined by RFC 9562.
+	IDUUIDv7 = "uuidv7"
+	// IDULID Hands out time ordered ULIDs encoded with Crockford's base32.
+	IDULID = "ulid"
+)
+
+// idGenerator Hands out and validates the task IDs of a scheme.
+type idGenerator interface {
+	// next Returns a new ID. The sequence is the number of IDs handed out
+	// including the new one.
+	next(sequence uint64) (string, error)
+	// valid Returns true if the ID has the format of the scheme.
+	valid(id string) bool
+}
+
+// newIDGenerator Returns the generator of the ID scheme. The
+// sequential scheme is used if the scheme is empty.
+func newIDGenerator(scheme string) (idGenerator, error) {
+	switch scheme {
+	case "", IDSequential:
+		return sequentialIDs{}, nil
+	case IDRandom:
+		return randomIDs{}, nil
+	case IDUUIDv7:
+		return uuidv7IDs{}, nil
+	case IDULID:
+		return ulidIDs{}, nil
+	}
+	return nil, fmt.Errorf("task: Unknown ID scheme %v", scheme)
+}
+
+type sequentialIDs struct{}
+
+func (sequentialIDs) next(sequence uint64) (string, error) {
+	return strconv.FormatUint(sequence, 10), nil
+}
+
+func (sequentialIDs) valid(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err == nil
+}
+
+type randomIDs struct{}
+
+func (randomIDs) next(sequence uint64) (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func (randomIDs) valid(id string) bool {
+	return len(id) == 32 && isLowerHex(id)
+}
+
+type uuidv7IDs struct{}
+
+func (uuidv7IDs) next(sequence uint64) (string, error) {
+	id, err := timestampedID()
+	if err != nil {
+		return "", err
+	}
+
+	// Version 7 and variant 10
+	id[6] = 0x70 | id[6]&0x0f
+	id[8] = 0x80 | id[8]&0x3f
+
+	text := hex.EncodeToString(id[:])
+	return text[0:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:], nil
+}
+
+func (uuidv7IDs) valid(id string) bool {
+	if len(id) != 36 || id[8] != '-' || id[13] != '-' || id[18] != '-' || id[23] != '-' {
+		return false
+	}
+
+	digits := strings.Replace(id, "-", "", 4)
+	if len(digits) != 32 || !isLowerHex(digits) {
+		return false
+	}
+
+	return id[14] == '7' && strings.IndexByte("89ab", id[19]) >= 0
+}
+
+type ulidIDs struct{}
+
+func (ulidIDs) next(sequence uint64) (string, error) {
+	id, err := timestampedID()
+	if err != nil {
+		return "", err
+	}
+
+	// 128 bits are encoded in 26 characters of 5 bits each. The first
+	// character only holds the 3 most significant bits.
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	text := make([]byte, 26)
+	for i := len(text) - 1; i >= 0; i-- {
+		text[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(text), nil
+}
+
+func (ulidIDs) valid(id string) bool {
+	if len(id) != 26 || id[0] > '7' {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(crockford, id[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// timestampedID Returns 128 bits that start with the 48-bit Unix time in
+// milliseconds followed by random bits. This layout is shared by UUIDv7
+// and ULID so the IDs sort by creation time.
+func timestampedID() ([16]byte, error) {
+	var id [16]byte
+
+	_, err := rand.Read(id[6:])
+	if err != nil {
+		return id, err
+	}
+
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+
+	return id, nil
+}
+
+func isLowerHex(text string) bool {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// crockford The base32 alphabet used by ULID.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
@@ -0,0 +1,94 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func TestIDGenerator_Schemes(t *testing.T) {
+	for _, scheme := range []string{IDSequential, IDRandom, IDUUIDv7, IDULID} {
+		ids, err := newIDGenerator(scheme)
+		if err != nil {
+			t.Errorf("newIDGenerator failed: %v", err)
+			continue
+		}
+
+		first, err := ids.next(1)
+		if err != nil {
+			t.Errorf("%v: next failed: %v", scheme, err)
+			continue
+		}
+
+		second, _ := ids.next(2)
+		if first == second {
+			t.Errorf("%v: IDs should be unique: %v", scheme, first)
+		}
+
+		if !ids.valid(first) || !ids.valid(second) {
+			t.Errorf("%v: Generated IDs should be valid: %v %v", scheme, first, second)
+		}
+	}
+
+	_, err := newIDGenerator("md5")
+	if err == nil {
+		t.Errorf("newIDGenerator should fail with an unknown scheme")
+	}
+}
+
+func TestIDGenerator_Invalid(t *testing.T) {
+	invalid := map[string][]string{
+		IDSequential: {"", "abc", "-1"},
+		IDRandom:     {"1", "0123456789ABCDEF0123456789ABCDEF", "0123456789abcdef0123456789abcdeg"},
+		IDUUIDv7:     {"1", "01890a5d-ac96-4ed0-8a8b-0123456789ab", "01890a5d-ac96-7ed0-ca8b-0123456789ab"},
+		IDULID:       {"1", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "01arz3ndektsv4rrffq69g5fav"},
+	}
+
+	for scheme, ids := range invalid {
+		generator, _ := newIDGenerator(scheme)
+		for _, id := range ids {
+			if generator.valid(id) {
+				t.Errorf("%v: ID should be invalid: %v", scheme, id)
+			}
+		}
+	}
+
+	generator, _ := newIDGenerator(IDUUIDv7)
+	if !generator.valid("01890a5d-ac96-7ed0-8a8b-0123456789ab") {
+		t.Errorf("UUIDv7 should be valid")
+	}
+
+	generator, _ = newIDGenerator(IDULID)
+	if !generator.valid("01ARZ3NDEKTSV4RRFFQ69G5FAV") {
+		t.Errorf("ULID should be valid")
+	}
+}
+
+func TestManager_RandomIDs(t *testing.T) {
+	conf := config.Config{}
+	conf.IDScheme = IDRandom
+	conf.MaxCompletedTasks = 1
+	mgr := NewManager(conf)
+
+	first := mgr.NewTask(Request{Password: []byte("pass1")})
+	if first.Code != 201 || len(first.Message) != 32 {
+		t.Errorf("NewTask should return 201 with a random ID, not %+v", first)
+	}
+	mgr.WaitForPendingTasks()
+
+	second := mgr.NewTask(Request{Password: []byte("pass2")})
+	mgr.WaitForPendingTasks()
+
+	codes := map[string]int{
+		"1":                                400,
+		first.Message:                      410,
+		second.Message:                     200,
+		"0123456789abcdef0123456789abcdef": 404,
+	}
+	for hashID, code := range codes {
+		res := mgr.Check(hashID)
+		if res.Code != code {
+			t.Errorf("Check %v should return %v, not %v", hashID, code, res.Code)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package task
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"time"
+)
+
+// idempotentRequest A task creation request identified by an idempotency key.
+type idempotentRequest struct {
+	key string
+	// fingerprint Identifies the request payload without keeping the password.
+	fingerprint []byte
+	result      Result
+	expires     time.Time
+}
+
+// fingerprint Returns the HMAC of the request payload. The HMAC key is
+// random and never leaves the task manager so the fingerprint cannot be
+// used to guess the password.
+func (tm *Manager) fingerprint(req Request) []byte {
+	mac := hmac.New(sha256.New, tm.fingerprintKey)
+	mac.Write([]byte(req.Algorithm))
+	mac.Write([]byte{0})
+	mac.Write([]byte(req.Policy))
+	mac.Write([]byte{0})
+	mac.Write(req.Password)
+	for _, value := range req.Context {
+		mac.Write([]byte{0})
+		mac.Write([]byte(value))
+	}
+	return mac.Sum(nil)
+}
+
+// replay Returns the result of the first request that used the key. A
+// request that reuses the key with a different payload is rejected with
+// status 422. This call returns false if the key is unknown or has expired.
+// The caller must hold the mutex.
+func (tm *Manager) replay(key string, fingerprint []byte, now time.Time) (Result, bool) {
+	tm.evictExpiredKeys(now)
+
+	request, exists := tm.idempotentRequests[key]
+	if !exists {
+		return Result{}, false
+	}
+
+	if !hmac.Equal(request.fingerprint, fingerprint) {
+		result := Result{}
+		result.Message = "Idempotency key was used with a different request"
+		result.Code = 422
+		return result, true
+	}
+
+	return request.result, true
+}
+
+// remember Keeps the result of the request for the idempotency window.
+// The caller must hold the mutex.
+func (tm *Manager) remember(key string, fingerprint []byte, result Result, now time.Time) {
+	window := time.Duration(tm.conf().IdempotencyWindowSeconds) * time.Second
+	request := &idempotentRequest{
+		key:         key,
+		fingerprint: fingerprint,
+		result:      result,
+		expires:     now.Add(window),
+	}
+
+	tm.idempotentRequests[key] = request
+	tm.idempotencyOrder.PushBack(request)
+}
+
+// evictExpiredKeys Forgets the keys whose window has passed. The keys are
+// ordered by expiration since the window is the same for every key.
+// The caller must hold the mutex.
+func (tm *Manager) evictExpiredKeys(now time.Time) {
+	for element := tm.idempotencyOrder.Front(); element != nil; element = tm.idempotencyOrder.Front() {
+		request := element.Value.(*idempotentRequest)
+		if now.Before(request.expires) {
+			return
+		}
+		tm.idempotencyOrder.Remove(element)
+		delete(tm.idempotentRequests, request.key)
+	}
+}
+
+const (
+	// maxIdempotencyKeyLength The maximum number of bytes in an idempotency key.
+	maxIdempotencyKeyLength = 255
+
+	// fingerprintKeyLength The number of bytes in the fingerprint HMAC key.
+	fingerprintKeyLength = 32
+)
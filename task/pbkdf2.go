@@ -0,0 +1,131 @@
+package task
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func newPBKDF2Hasher(conf config.Config) Hasher {
+	h := pbkdf2Hasher{
+		iterations: conf.PBKDF2.Iterations,
+		saltLength: defaultSaltLength,
+		keyLength:  defaultKeyLength,
+	}
+	if h.iterations == 0 {
+		h.iterations = 600000
+	}
+	return h
+}
+
+// pbkdf2Hasher Hashes passwords with PBKDF2 using HMAC-SHA256. The hash is
+// encoded using the PHC string format. For example: $pbkdf2-sha256$i=600000$salt$hash
+type pbkdf2Hasher struct {
+	iterations int
+	saltLength int
+	keyLength  int
+}
+
+func (h pbkdf2Hasher) Name() string {
+	return PBKDF2SHA256
+}
+
+func (h pbkdf2Hasher) Hash(password []byte) (string, error) {
+	salt, err := newSalt(h.saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	hash := pbkdf2.Key(password, salt, h.iterations, h.keyLength, sha256.New)
+
+	params := fmt.Sprintf("i=%d", h.iterations)
+	return encodePHC(PBKDF2SHA256, params, salt, hash), nil
+}
+
+func (h pbkdf2Hasher) Verify(password []byte, encoded string) (bool, error) {
+	params, phc, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	hash := pbkdf2.Key(password, phc.Salt, params.iterations, params.keyLength, sha256.New)
+
+	return subtle.ConstantTimeCompare(hash, phc.Hash) == 1, nil
+}
+
+func (h pbkdf2Hasher) NeedsRehash(encoded string) (bool, error) {
+	params, _, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+	return params != h, nil
+}
+
+// decode Returns a hasher with the parameters found in the encoded hash.
+func (h pbkdf2Hasher) decode(encoded string) (pbkdf2Hasher, phcHash, error) {
+	params := pbkdf2Hasher{}
+
+	phc, err := decodePHC(encoded)
+	if err != nil {
+		return params, phc, err
+	}
+	if phc.ID != PBKDF2SHA256 {
+		return params, phc, fmt.Errorf("task: Invalid pbkdf2-sha256 hash")
+	}
+
+	iterations, err := phc.intParam("i", 31)
+	if err != nil {
+		return params, phc, err
+	}
+
+	// The iterations come from clients so they must not hold a worker for long
+	if iterations > costLimit(uint64(h.iterations), maxPBKDF2Iterations) {
+		return params, phc, fmt.Errorf("task: pbkdf2-sha256 iterations exceed the limit")
+	}
+
+	params.iterations = int(iterations)
+	params.saltLength = len(phc.Salt)
+	params.keyLength = len(phc.Hash)
+
+	return params, phc, nil
+}
+
+// Calibrate Scales the iterations linearly from a baseline benchmark.
+func (h pbkdf2Hasher) Calibrate(conf *config.Config, target time.Duration) (Calibration, error) {
+	calibration := Calibration{Algorithm: PBKDF2SHA256}
+	params := h
+	params.iterations = pbkdf2Baseline
+
+	duration, err := benchmark(params)
+	if err != nil {
+		return calibration, err
+	}
+
+	// Round to the nearest thousand iterations
+	params.iterations = scale(params.iterations, duration, target, 1000) / 1000 * 1000
+	if params.iterations > maxPBKDF2Iterations {
+		params.iterations = maxPBKDF2Iterations
+	}
+
+	calibration.Duration, err = benchmark(params)
+	if err != nil {
+		return calibration, err
+	}
+
+	conf.PBKDF2.Iterations = params.iterations
+	calibration.Parameters = fmt.Sprintf("i=%d", params.iterations)
+
+	return calibration, nil
+}
+
+const (
+	pbkdf2Baseline = 10000
+	// maxPBKDF2Iterations The most iterations a verified hash can use
+	// whatever the configuration.
+	maxPBKDF2Iterations = 10000000
+)
@@ -0,0 +1,147 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/pepper"
+)
+
+// pepperHasher Peppers the password before it is passed to the inner hasher.
+// The key version is embedded in the hash so verification picks the right
+// key. For example: $pepper$v=2$argon2id$v=19$m=19456,t=2,p=1$salt$hash
+type pepperHasher struct {
+	inner Hasher
+	ring  *pepper.KeyRing
+}
+
+func (h pepperHasher) Name() string {
+	return h.inner.Name()
+}
+
+func (h pepperHasher) Hash(password []byte) (string, error) {
+	peppered, err := h.ring.Apply(h.ring.Current, password)
+	if err != nil {
+		return "", err
+	}
+	defer zero(peppered)
+
+	hash, err := h.inner.Hash(peppered)
+	if err != nil {
+		return "", err
+	}
+
+	return encodePepper(h.ring.Current, hash), nil
+}
+
+func (h pepperHasher) Verify(password []byte, encoded string) (bool, error) {
+	version, inner, err := decodePepper(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	peppered, err := h.ring.Apply(version, password)
+	if err != nil {
+		return false, err
+	}
+	defer zero(peppered)
+
+	return h.inner.Verify(peppered, inner)
+}
+
+func (h pepperHasher) NeedsRehash(encoded string) (bool, error) {
+	version, inner, err := decodePepper(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	outdated, err := h.inner.NeedsRehash(inner)
+	if err != nil {
+		return false, err
+	}
+
+	return outdated || version != h.ring.Current, nil
+}
+
+func isPeppered(encoded string) bool {
+	return strings.HasPrefix(encoded, pepperPrefix)
+}
+
+func encodePepper(version uint, hash string) string {
+	prefix := fmt.Sprintf("%sv=%d", pepperPrefix, version)
+	if strings.HasPrefix(hash, "$") {
+		return prefix + hash
+	}
+	return prefix + "$" + hash
+}
+
+// decodePepper Returns the key version and the inner hash. The inner
+// hash keeps its leading '$' unless it is a base-64 SHA512 hash.
+func decodePepper(encoded string) (uint, string, error) {
+	if !isPeppered(encoded) {
+		return 0, "", fmt.Errorf("task: Invalid pepper hash")
+	}
+
+	rest := strings.TrimPrefix(encoded, pepperPrefix+"v=")
+	end := strings.Index(rest, "$")
+	if end < 0 || rest == encoded {
+		return 0, "", fmt.Errorf("task: Invalid pepper hash")
+	}
+
+	version, err := strconv.ParseUint(rest[:end], 10, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("task: Invalid pepper version %v", rest[:end])
+	}
+
+	inner := rest[end:]
+	if !strings.Contains(inner[1:], "$") {
+		inner = inner[1:]
+	}
+
+	return uint(version), inner, nil
+}
+
+// keyRings Caches the configured key ring so it is only loaded again
+// after the key file changes. This allows keys to be rotated without
+// restarting the service.
+var keyRings = struct {
+	sync.Mutex
+	path    string
+	size    int64
+	modTime time.Time
+	ring    *pepper.KeyRing
+}{}
+
+func loadKeyRing(conf config.Config) (*pepper.KeyRing, error) {
+	info, err := os.Stat(conf.PepperFile)
+	if err != nil {
+		return nil, fmt.Errorf("task: Failed to load pepper: %v", err)
+	}
+
+	keyRings.Lock()
+	defer keyRings.Unlock()
+
+	if keyRings.path == conf.PepperFile && keyRings.size == info.Size() &&
+		keyRings.modTime.Equal(info.ModTime()) {
+		return keyRings.ring, nil
+	}
+
+	ring, err := pepper.OpenFile(conf.PepperFile)
+	if err != nil {
+		return nil, fmt.Errorf("task: Failed to load pepper: %v", err)
+	}
+
+	keyRings.path = conf.PepperFile
+	keyRings.size = info.Size()
+	keyRings.modTime = info.ModTime()
+	keyRings.ring = ring
+
+	return ring, nil
+}
+
+const pepperPrefix = "$pepper$"
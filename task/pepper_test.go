@@ -0,0 +1,109 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/pepper"
+)
+
+func TestPepper_Rotation(t *testing.T) {
+	file := "./Pepper_Rotation"
+	defer os.Remove(file)
+
+	_, err := pepper.RotateFile(file)
+	if err != nil {
+		t.Errorf("RotateFile failed: %v", err)
+		return
+	}
+
+	conf := config.Config{PepperFile: file}
+
+	for i, name := range []string{SHA512, Argon2id} {
+		hasher, err := NewHasher(name, conf)
+		if err != nil {
+			t.Errorf("NewHasher failed: %v", err)
+			return
+		}
+
+		hash, err := hasher.Hash([]byte("angryMonkey"))
+		if err != nil {
+			t.Errorf("%v: Hash failed: %v", name, err)
+			continue
+		}
+		if !strings.HasPrefix(hash, fmt.Sprintf("$pepper$v=%d$", i+1)) {
+			t.Errorf("%v: Hash should embed the key version: %v", name, hash)
+		}
+
+		plain, _ := newHasher(name, conf)
+		_, inner, _ := decodePepper(hash)
+		if match, _ := plain.Verify([]byte("angryMonkey"), inner); match {
+			t.Errorf("%v: Hash should not match without the pepper", name)
+		}
+
+		// Rotating keeps the old key so the hash can still be verified
+		_, err = pepper.RotateFile(file)
+		if err != nil {
+			t.Errorf("RotateFile failed: %v", err)
+			return
+		}
+
+		identified, err := IdentifyHasher(hash, conf)
+		if err != nil {
+			t.Errorf("%v: IdentifyHasher failed: %v", name, err)
+			continue
+		}
+
+		match, err := identified.Verify([]byte("angryMonkey"), hash)
+		if err != nil || !match {
+			t.Errorf("%v: Verify should match after rotation: %v", name, err)
+		}
+
+		outdated, err := NeedsRehash(conf, hash)
+		if err != nil || !outdated {
+			t.Errorf("%v: NeedsRehash should be true after rotation: %v", name, err)
+		}
+	}
+}
+
+func TestPepper_NotConfigured(t *testing.T) {
+	conf := config.Config{PepperFile: "./missing"}
+
+	_, err := NewHasher(SHA512, conf)
+	if err == nil {
+		t.Errorf("NewHasher should fail with a missing pepper file")
+	}
+
+	_, err = IdentifyHasher("$pepper$v=1$abc", config.Config{})
+	if err == nil {
+		t.Errorf("IdentifyHasher should fail without a pepper file")
+	}
+
+	mgr := NewManager(conf)
+	res := mgr.NewTask(Request{Password: []byte("pass")})
+	if res.Code != 500 {
+		t.Errorf("NewTask should return 500, not %v", res.Code)
+	}
+}
+
+func TestDecodePepper(t *testing.T) {
+	version, inner, err := decodePepper("$pepper$v=7$$argon2id$v=19")
+	if err != nil || version != 7 || inner != "$$argon2id$v=19" {
+		t.Errorf("decodePepper failed: %v %v %v", version, inner, err)
+	}
+
+	version, inner, err = decodePepper("$pepper$v=3$c2FsdA==")
+	if err != nil || version != 3 || inner != "c2FsdA==" {
+		t.Errorf("decodePepper failed: %v %v %v", version, inner, err)
+	}
+
+	for _, hash := range []string{"$argon2id$v=19", "$pepper$x=1$abc", "$pepper$v=a$abc", "$pepper$v=1"} {
+		_, _, err = decodePepper(hash)
+		if err == nil {
+			t.Errorf("decodePepper should fail with %v", hash)
+		}
+	}
+}
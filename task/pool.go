@@ -0,0 +1,97 @@
+package task
+
+import (
+	"sync"
+)
+
+// pool A fixed-size worker pool with a bounded queue. Jobs are run
+// in the order they were submitted.
+type pool struct {
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	queue     []*job
+	queueSize int
+	workers   int
+}
+
+// job A unit of work queued in the pool.
+type job struct {
+	run func()
+}
+
+func newPool(workers, queueSize int) *pool {
+	p := &pool{workers: workers, queueSize: queueSize}
+	p.cond = sync.NewCond(&p.mutex)
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+// submit Queues the job. This call returns false without
+// queuing the job if the queue is full.
+func (p *pool) submit(run func()) (*job, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.queue) >= p.queueSize {
+		return nil, false
+	}
+
+	j := &job{run: run}
+	p.queue = append(p.queue, j)
+	p.cond.Signal()
+
+	return j, true
+}
+
+// resume Queues the job even if the queue is full. This is used to
+// queue the tasks that were interrupted by a restart.
+func (p *pool) resume(run func()) *job {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	j := &job{run: run}
+	p.queue = append(p.queue, j)
+	p.cond.Signal()
+
+	return j
+}
+
+// queued Returns the number of jobs waiting for a worker.
+func (p *pool) queued() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.queue)
+}
+
+// position Returns the zero based position of the job in the queue.
+// This call returns -1 if the job is not waiting for a worker.
+func (p *pool) position(j *job) int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i, queued := range p.queue {
+		if queued == j {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *pool) work() {
+	for {
+		p.mutex.Lock()
+		for len(p.queue) == 0 {
+			p.cond.Wait()
+		}
+		j := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mutex.Unlock()
+
+		j.run()
+	}
+}
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPool_Order(t *testing.T) {
+	p := newPool(1, 10)
+
+	var wg sync.WaitGroup
+	var order []int
+	block := make(chan struct{})
+
+	wg.Add(4)
+	p.submit(func() { <-block; wg.Done() })
+	for i := 0; i < 3; i++ {
+		i := i
+		p.submit(func() { order = append(order, i); wg.Done() })
+	}
+
+	close(block)
+	wg.Wait()
+
+	for i, value := range order {
+		if i != value {
+			t.Errorf("Jobs should run in order: %v", order)
+			return
+		}
+	}
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	p := newPool(1, 1)
+	block := make(chan struct{})
+	defer close(block)
+
+	started := make(chan struct{})
+	p.submit(func() { close(started); <-block })
+	<-started
+
+	j, ok := p.submit(func() {})
+	if !ok {
+		t.Errorf("submit should queue the job")
+	}
+	if p.queued() != 1 || p.position(j) != 0 {
+		t.Errorf("queued should be 1, not %v", p.queued())
+	}
+	if _, ok = p.submit(func() {}); ok {
+		t.Errorf("submit should fail when the queue is full")
+	}
+	if p.position(&job{}) != -1 {
+		t.Errorf("position should be -1 for a job that is not queued")
+	}
+}
@@ -0,0 +1,163 @@
+package task
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func newScryptHasher(conf config.Config) Hasher {
+	h := scryptHasher{
+		logN:       conf.Scrypt.LogN,
+		r:          conf.Scrypt.BlockSize,
+		p:          conf.Scrypt.Parallelism,
+		saltLength: defaultSaltLength,
+		keyLength:  defaultKeyLength,
+	}
+
+	// Use the recommended parameters when they are not configured
+	if h.logN == 0 {
+		h.logN = 15
+	}
+	if h.r == 0 {
+		h.r = 8
+	}
+	if h.p == 0 {
+		h.p = 1
+	}
+
+	return h
+}
+
+// scryptHasher Hashes passwords with scrypt. The hash is encoded using
+// the PHC string format. For example: $scrypt$ln=15,r=8,p=1$salt$hash
+type scryptHasher struct {
+	logN       uint
+	r          int
+	p          int
+	saltLength int
+	keyLength  int
+}
+
+func (h scryptHasher) Name() string {
+	return Scrypt
+}
+
+func (h scryptHasher) Hash(password []byte) (string, error) {
+	salt, err := newSalt(h.saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := scrypt.Key(password, salt, 1<<h.logN, h.r, h.p, h.keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	params := fmt.Sprintf("ln=%d,r=%d,p=%d", h.logN, h.r, h.p)
+	return encodePHC(Scrypt, params, salt, hash), nil
+}
+
+func (h scryptHasher) Verify(password []byte, encoded string) (bool, error) {
+	params, phc, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := scrypt.Key(password, phc.Salt, 1<<params.logN, params.r, params.p, params.keyLength)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(hash, phc.Hash) == 1, nil
+}
+
+func (h scryptHasher) NeedsRehash(encoded string) (bool, error) {
+	params, _, err := h.decode(encoded)
+	if err != nil {
+		return false, err
+	}
+	return params != h, nil
+}
+
+// decode Returns a hasher with the parameters found in the encoded hash.
+func (h scryptHasher) decode(encoded string) (scryptHasher, phcHash, error) {
+	params := scryptHasher{}
+
+	phc, err := decodePHC(encoded)
+	if err != nil {
+		return params, phc, err
+	}
+	if phc.ID != Scrypt {
+		return params, phc, fmt.Errorf("task: Invalid scrypt hash")
+	}
+
+	logN, err := phc.intParam("ln", 6)
+	if err != nil {
+		return params, phc, err
+	}
+	r, err := phc.intParam("r", 30)
+	if err != nil {
+		return params, phc, err
+	}
+	p, err := phc.intParam("p", 30)
+	if err != nil {
+		return params, phc, err
+	}
+
+	// The parameters come from clients so they must not exhaust the server
+	memory := uint64(128*h.r) << h.logN
+	if logN > maxScryptLogN || 128*r<<logN > costLimit(memory, maxScryptMemory) ||
+		r*p > costLimit(uint64(h.r*h.p), maxScryptRP) {
+		return params, phc, fmt.Errorf("task: scrypt parameters exceed the limits")
+	}
+
+	params.logN = uint(logN)
+	params.r = int(r)
+	params.p = int(p)
+	params.saltLength = len(phc.Salt)
+	params.keyLength = len(phc.Hash)
+
+	return params, phc, nil
+}
+
+// Calibrate Uses the highest N whose hash does not take longer than the
+// target. The block size and parallelism are not changed. Each increment
+// of log2(N) doubles both the hash duration and memory.
+func (h scryptHasher) Calibrate(conf *config.Config, target time.Duration) (Calibration, error) {
+	calibration := Calibration{Algorithm: Scrypt}
+	params := h
+
+	for params.logN = minScryptLogN; params.logN <= maxScryptLogN; params.logN++ {
+		duration, err := benchmark(params)
+		if err != nil {
+			return calibration, err
+		}
+		if duration > target && params.logN > minScryptLogN {
+			break
+		}
+		calibration.Duration = duration
+	}
+
+	conf.Scrypt.LogN = params.logN - 1
+	conf.Scrypt.BlockSize = h.r
+	conf.Scrypt.Parallelism = h.p
+	calibration.Parameters = fmt.Sprintf("ln=%d,r=%d,p=%d", conf.Scrypt.LogN, h.r, h.p)
+
+	return calibration, nil
+}
+
+const (
+	minScryptLogN = 10
+	// maxScryptLogN Limits the memory to 1 GiB when r=8
+	maxScryptLogN = 20
+	// maxScryptMemory The most memory in bytes a verified hash can use
+	// whatever the configuration.
+	maxScryptMemory = 1 << 30
+	// maxScryptRP Limits the work of the parallel mixes of a verified hash.
+	maxScryptRP = 64
+)
@@ -0,0 +1,45 @@
+package task
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+func newSHA512Hasher(conf config.Config) Hasher {
+	return sha512Hasher{}
+}
+
+// sha512Hasher The original unsalted SHA512 hasher. The hash is encoded as
+// a base-64 string to remain compatible with existing clients.
+type sha512Hasher struct{}
+
+func (h sha512Hasher) Name() string {
+	return SHA512
+}
+
+func (h sha512Hasher) Hash(password []byte) (string, error) {
+	hash := sha512.Sum512(password)
+	return base64.StdEncoding.EncodeToString(hash[:]), nil
+}
+
+func (h sha512Hasher) Verify(password []byte, encoded string) (bool, error) {
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(expected) != sha512.Size {
+		return false, fmt.Errorf("task: Invalid sha512 hash")
+	}
+
+	hash := sha512.Sum512(password)
+	return subtle.ConstantTimeCompare(hash[:], expected) == 1, nil
+}
+
+func (h sha512Hasher) NeedsRehash(encoded string) (bool, error) {
+	hash, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(hash) != sha512.Size {
+		return false, fmt.Errorf("task: Invalid sha512 hash")
+	}
+	return false, nil
+}
@@ -0,0 +1,92 @@
+package task
+
+import (
+	"time"
+
+	"github.com/jrpalma/pwdhash/config"
+)
+
+// Task Represents the state of a password hash task.
+type Task struct {
+	// ID The ID used to check the task.
+	ID string
+	// Algorithm The name of the hash algorithm.
+	Algorithm string
+	// Policy The name of the password policy the password passed.
+	Policy string
+	// Done True once the password has been hashed.
+	Done bool
+	// Hash The encoded password hash. This is set once the task is done.
+	Hash string
+	// Err The reason the password could not be hashed.
+	Err string
+	// Created The time the task was accepted.
+	Created time.Time
+	// Completed The time the task was done.
+	Completed time.Time
+}
+
+// TaskStore Stores the password hash tasks. Implementations must be
+// safe for concurrent use.
+type TaskStore interface {
+	// Accept Stores a task that has been accepted. Stores that survive
+	// restarts keep the password until the task is done so the task can
+	// be resumed. The store does not take ownership of the password.
+	Accept(task Task, password []byte) error
+	// Update Stores the new state of an existing task.
+	Update(task Task) error
+	// Get Returns the task with the given ID.
+	Get(id string) (Task, bool)
+	// Delete Removes the tasks with the given IDs. Stores that survive
+	// restarts remove all of them with a single write.
+	Delete(ids ...string) error
+	// Recover Returns the stored tasks and the passwords of the tasks
+	// that were accepted but never completed.
+	Recover() ([]Task, map[string][]byte, error)
+	// Close Closes the store.
+	Close() error
+}
+
+// OpenStore Opens the task store set by the configuration. The tasks are
+// kept in a file-backed store in the data directory if one is configured.
+// The passwords of the pending tasks are only stored if a key file is set.
+// Otherwise, the tasks are only kept in memory.
+func OpenStore(conf config.Config) (TaskStore, error) {
+	if conf.DataDirectory == "" {
+		return NewMemoryStore(), nil
+	}
+	return OpenFileStore(conf.DataDirectory, conf.DataKeyFile)
+}
+
+// NewMemoryStore Creates a store for the tasks that are only kept in
+// memory. The manager's map is the only copy of the tasks so the store
+// keeps nothing and the tasks do not survive restarts.
+func NewMemoryStore() TaskStore {
+	return memoryStore{}
+}
+
+type memoryStore struct{}
+
+func (s memoryStore) Accept(task Task, password []byte) error {
+	return nil
+}
+
+func (s memoryStore) Update(task Task) error {
+	return nil
+}
+
+func (s memoryStore) Get(id string) (Task, bool) {
+	return Task{}, false
+}
+
+func (s memoryStore) Delete(ids ...string) error {
+	return nil
+}
+
+func (s memoryStore) Recover() ([]Task, map[string][]byte, error) {
+	return nil, make(map[string][]byte), nil
+}
+
+func (s memoryStore) Close() error {
+	return nil
+}
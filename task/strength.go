@@ -0,0 +1,117 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/strength"
+)
+
+// CheckStrength Checks the password against the same strength policy
+// enforced by NewTask without creating a task. Only the password, the
+// user context, and the policy of the request are used. The password is
+// zeroed once it is checked and it is never kept.
+func (tm *Manager) CheckStrength(req Request) (StrengthCheck, Result) {
+	check := StrengthCheck{}
+	result := Result{}
+
+	pwd := req.Password
+	defer zero(pwd)
+
+	conf := tm.conf()
+
+	if tm.done {
+		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
+		result.Code = 500
+		return check, result
+	}
+
+	rules, _, exists := conf.Policy(req.Policy)
+	if !exists {
+		result.Message = fmt.Sprintf("Unknown password policy %v", req.Policy)
+		result.Code = 400
+		return check, result
+	}
+
+	report, err := tm.evaluate(conf, rules, pwd, req.Context)
+	if err != nil {
+		result.Message = blocklistMsg
+		result.Code = 500
+		return check, result
+	}
+
+	// The score is reported even if the policy has no minimum score
+	if report.Estimate == nil {
+		estimate := strength.Entropy(string(pwd))
+		report.Estimate = &estimate
+	}
+
+	check.Passed = report.Passed
+	check.Score = report.Estimate.Score
+	check.CrackTime = report.Estimate.CrackTime
+	check.Violations = report.Violations
+	check.Suggestions = strength.Suggest(report)
+	if check.Violations == nil {
+		check.Violations = []strength.Violation{}
+	}
+	if check.Suggestions == nil {
+		check.Suggestions = []string{}
+	}
+
+	result.Code = 200
+	return check, result
+}
+
+// evaluate Checks the password against the strength rules of a policy and
+// the breached passwords of the configuration. This call returns an error
+// if the breached passwords cannot be checked.
+func (tm *Manager) evaluate(conf config.Config, rules strength.PasswordStrength, password []byte, context []string) (strength.Report, error) {
+	report := strength.Report{Passed: true}
+	if conf.CheckPasswordStrength {
+		report = strength.Evaluate(rules, string(password), context...)
+	}
+
+	if conf.BreachFile != "" {
+		err := tm.checkBreached(conf, &report, password)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// checkBreached Adds a violation to the report if the password was seen
+// in more breaches than the configured threshold. The blocklist is opened
+// the first time it is needed.
+func (tm *Manager) checkBreached(conf config.Config, report *strength.Report, password []byte) error {
+	tm.mutex.Lock()
+	if tm.blocklist == nil {
+		blocklist, err := strength.OpenBlocklist(conf.BreachFile)
+		if err != nil {
+			tm.mutex.Unlock()
+			return fmt.Errorf("task: Failed to open blocklist: %v", err)
+		}
+		tm.blocklist = blocklist
+	}
+	blocklist := tm.blocklist
+	tm.mutex.Unlock()
+
+	count, err := blocklist.Count(password)
+	if err != nil {
+		return err
+	}
+
+	threshold := conf.BreachThreshold
+	if count > threshold {
+		report.Passed = false
+		report.Violations = append(report.Violations, strength.Violation{
+			Rule:     strength.RuleBreached,
+			Required: threshold,
+			Actual:   count,
+		})
+	}
+
+	return nil
+}
@@ -1,11 +1,14 @@
 package task
 
 import (
-	"crypto/sha512"
-	"encoding/base64"
+	"container/list"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jrpalma/pwdhash/config"
@@ -13,9 +16,73 @@ import (
 )
 
 // NewManager Creates a new task manager with the given configuration.
+// The tasks are only kept in memory. The number of workers and the
+// queue size default to 64 and 10000 respectively if they are not configured.
+// Random IDs are used if the ID scheme is unknown.
 func NewManager(config config.Config) *Manager {
-	tm := &Manager{config: config}
-	tm.tasks = make(map[uint64]*task)
+	return newManager(config, NewMemoryStore())
+}
+
+// OpenManager Creates a new task manager that keeps the tasks in the store
+// set by the configuration. The tasks found in the store are recovered and
+// the tasks that were never completed are queued again.
+func OpenManager(config config.Config) (*Manager, error) {
+	_, err := newIDGenerator(config.IDScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := OpenStore(config)
+	if err != nil {
+		return nil, fmt.Errorf("task: Failed to open store: %v", err)
+	}
+
+	tm := newManager(config, store)
+
+	err = tm.recover()
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return tm, nil
+}
+
+func newManager(config config.Config, store TaskStore) *Manager {
+	var err error
+
+	tm := &Manager{store: store}
+	tm.config.Store(config)
+	tm.tasks = make(map[string]*task)
+
+	tm.ids, err = newIDGenerator(config.IDScheme)
+	if err != nil {
+		tm.ids = randomIDs{}
+	}
+
+	workers := int(config.Workers)
+	if workers == 0 {
+		workers = defaultWorkers
+	}
+	queueSize := int(config.QueueSize)
+	if queueSize == 0 {
+		queueSize = defaultQueueSize
+	}
+	tm.pool = newPool(workers, queueSize)
+
+	tm.completed = list.New()
+	tm.evicted = make(map[string]struct{})
+	tm.evictedOrder = list.New()
+	tm.idempotentRequests = make(map[string]*idempotentRequest)
+	tm.idempotencyOrder = list.New()
+	tm.fingerprintKey = make([]byte, fingerprintKeyLength)
+	rand.Read(tm.fingerprintKey)
+
+	tm.stopJanitor = make(chan struct{})
+	if config.TaskRetentionSeconds > 0 {
+		go tm.janitor()
+	}
+
 	return tm
 }
 
@@ -25,6 +92,35 @@ type Result struct {
 	Code int
 	// Message The HTTP message to use in a response.
 	Message string
+	// RetryAfter The number of seconds the client should wait before
+	// trying again. This is zero if the client should not retry.
+	RetryAfter uint
+	// Problem The kind of failure. This is one of the Problem constants
+	// or empty if the failure is only described by the status code.
+	Problem string
+	// Strength The rules the password does not pass. This is only set
+	// if the password is too weak.
+	Strength *strength.Report
+}
+
+// Request The parameters used to create a new password hash task.
+type Request struct {
+	// Password The password to hash. The task manager takes ownership of
+	// the password and zeroes it once it is no longer needed.
+	Password []byte
+	// Algorithm The name of the hash algorithm. The configured
+	// algorithm is used if this is empty.
+	Algorithm string
+	// IdempotencyKey The client key used to detect retried requests.
+	// A request that reuses a key returns the task created by the first
+	// request instead of creating a new one.
+	IdempotencyKey string
+	// Context The user information such as the username, email, or display
+	// name the password should not be derived from.
+	Context []string
+	// Policy The name of the password policy the password must pass. The
+	// default policy is used if this is empty.
+	Policy string
 }
 
 // Stats The task manager statistics
@@ -35,17 +131,99 @@ type Stats struct {
 	Average uint64 `json:"average"`
 }
 
+// TaskStatus The state of a password hash task reported to the clients.
+type TaskStatus struct {
+	// ID The hash ID.
+	ID string `json:"id"`
+	// Status The task status. This is one of pending, running, done, or failed.
+	Status string `json:"status"`
+	// Algorithm The name of the hash algorithm.
+	Algorithm string `json:"algorithm"`
+	// Policy The name of the password policy the password passed.
+	Policy string `json:"policy,omitempty"`
+	// Hash The encoded password hash. This is only set once the task is done.
+	Hash string `json:"hash,omitempty"`
+	// Error The reason the password could not be hashed.
+	Error string `json:"error,omitempty"`
+	// Created The time the task was accepted.
+	Created time.Time `json:"created"`
+	// Completed The time the task was done.
+	Completed *time.Time `json:"completed,omitempty"`
+	// EstimatedCompletion The time the task is expected to be done.
+	// This is only set if the task is not done.
+	EstimatedCompletion *time.Time `json:"estimatedCompletion,omitempty"`
+}
+
+// Verification The result of a password verification.
+type Verification struct {
+	// Match True if the password matches the hash.
+	Match bool `json:"match"`
+	// Hash The replacement hash created with the configured algorithm and
+	// parameters. This is only set when a rehash was requested, the password
+	// matches, and the hash is outdated.
+	Hash string `json:"hash,omitempty"`
+}
+
+// StrengthCheck The result of checking a password against the strength policy.
+type StrengthCheck struct {
+	// Passed True if the password would be accepted by NewTask.
+	Passed bool `json:"passed"`
+	// Score The strength score from 0 (too guessable) to 4 (very unguessable).
+	Score uint `json:"score"`
+	// CrackTime The estimated time to find the password offline in words.
+	CrackTime string `json:"crackTime"`
+	// Violations The rules the password does not pass.
+	Violations []strength.Violation `json:"violations"`
+	// Suggestions The advice to pick a stronger password.
+	Suggestions []string `json:"suggestions"`
+}
+
+// RehashCheck The result of checking if a hash needs to be recreated.
+type RehashCheck struct {
+	// NeedsRehash True if the hash was not created with the configured
+	// algorithm and parameters.
+	NeedsRehash bool `json:"needsRehash"`
+}
+
 // Manager A task manager is charage of all the password hash operations.
 type Manager struct {
 	taskID         uint64
 	taskRuntime    time.Duration
 	completedTasks uint64
+	recentHashTime time.Duration
+	hashedTasks    uint64
+
+	done  bool
+	ids   idGenerator
+	tasks map[string]*task
+	store TaskStore
+	pool  *pool
+	mutex sync.Mutex
+	wg    sync.WaitGroup
+
+	// config The current config.Config. Reload replaces it while the
+	// requests in progress keep the one they started with.
+	config atomic.Value
+
+	// completed The completed tasks ordered from the most to the
+	// least recently used.
+	completed   *list.List
+	stopJanitor chan struct{}
 
-	done   bool
-	config config.Config
-	tasks  map[uint64]*task
-	mutex  sync.Mutex
-	wg     sync.WaitGroup
+	// evicted The IDs of the most recently evicted tasks. evictedOrder
+	// keeps them ordered from the oldest to the newest eviction.
+	evicted      map[string]struct{}
+	evictedOrder *list.List
+
+	// idempotentRequests The requests that used an idempotency key.
+	// idempotencyOrder keeps them ordered from the oldest to the newest.
+	idempotentRequests map[string]*idempotentRequest
+	idempotencyOrder   *list.List
+	fingerprintKey     []byte
+
+	// blocklist The breached passwords. It is opened by the first
+	// request that needs it.
+	blocklist *strength.Blocklist
 }
 
 // Shutdown Shutdown the task manager. A call to WaitForPendingTasks is expected
@@ -54,13 +232,19 @@ type Manager struct {
 func (tm *Manager) Shutdown() Result {
 	result := Result{}
 
+	// The mutex makes sure the janitor is only stopped once
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
 	if tm.done {
 		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
 		result.Code = 500
 		return result
 	}
 
 	tm.done = true
+	close(tm.stopJanitor)
 	result.Code = 200
 
 	return result
@@ -71,6 +255,39 @@ func (tm *Manager) WaitForPendingTasks() {
 	tm.wg.Wait()
 }
 
+// Close Closes the task store. This call is expected after
+// WaitForPendingTasks so no task is left incomplete.
+func (tm *Manager) Close() error {
+	tm.mutex.Lock()
+	if tm.blocklist != nil {
+		tm.blocklist.Close()
+		tm.blocklist = nil
+	}
+	tm.mutex.Unlock()
+
+	return tm.store.Close()
+}
+
+// Reload Replaces the reloadable parts of the configuration with the ones
+// in next. The tasks in progress keep the configuration they started with.
+// This call returns the names of the values that changed but require a
+// restart. The configuration is expected to be valid.
+func (tm *Manager) Reload(next config.Config) []string {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	current := tm.conf()
+	merged, restart := current.Reload(next)
+	tm.config.Store(merged)
+
+	return restart
+}
+
+// conf Returns the current configuration.
+func (tm *Manager) conf() config.Config {
+	return tm.config.Load().(config.Config)
+}
+
 // Stats Returns the statisc object. This call might fail
 // it the shutdown is pending.
 func (tm *Manager) Stats() (Stats, Result) {
@@ -79,6 +296,7 @@ func (tm *Manager) Stats() (Stats, Result) {
 
 	if tm.done {
 		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
 		result.Code = 500
 		return stats, result
 	}
@@ -94,66 +312,190 @@ func (tm *Manager) Stats() (Stats, Result) {
 	return stats, result
 }
 
-// NewTask Creates a new password hash task. This call
-// might fail if shutdown is pending.
-func (tm *Manager) NewTask(pwd string) Result {
+// NewTask Creates a new password hash task. The password is zeroed as
+// soon as it is hashed or the task is rejected. A request that reuses an
+// idempotency key within the configured window returns the original result.
+// This call might fail if shutdown is pending, the algorithm is unknown,
+// or the idempotency key was used with a different request.
+func (tm *Manager) NewTask(req Request) Result {
+	var fingerprint []byte
 	result := Result{}
+	queued := false
+
+	// The whole request uses the configuration at the time it was made
+	conf := tm.conf()
+
+	defer func() {
+		if !queued {
+			zero(req.Password)
+		}
+	}()
 
 	if tm.done {
 		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
 		result.Code = 500
 		return result
 	}
 
-	if tm.config.CheckPasswordStrength {
-		strongPassword := strength.Check(tm.config.PasswordStrength, pwd)
-		if !strongPassword {
-			result.Message = fmt.Sprintf("Password is too weak")
+	idempotent := req.IdempotencyKey != "" && conf.IdempotencyWindowSeconds > 0
+	if idempotent {
+		if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+			result.Message = "Idempotency key is too long"
 			result.Code = 400
 			return result
 		}
+
+		fingerprint = tm.fingerprint(req)
+
+		tm.mutex.Lock()
+		original, replayed := tm.replay(req.IdempotencyKey, fingerprint, time.Now())
+		tm.mutex.Unlock()
+		if replayed {
+			return original
+		}
+	}
+
+	rules, policy, exists := conf.Policy(req.Policy)
+	if !exists {
+		result.Message = fmt.Sprintf("Unknown password policy %v", req.Policy)
+		result.Code = 400
+		return result
+	}
+
+	report, err := tm.evaluate(conf, rules, req.Password, req.Context)
+	if err != nil {
+		result.Message = blocklistMsg
+		result.Code = 500
+		return result
+	}
+
+	if !report.Passed {
+		result.Message = "Password is too weak"
+		result.Problem = ProblemWeakPassword
+		result.Strength = &report
+		result.Code = 400
+		return result
+	}
+
+	hasher, err := NewHasher(req.Algorithm, conf)
+	if errors.Is(err, ErrUnknownAlgorithm) {
+		result.Message = fmt.Sprintf("Unknown hash algorithm %v", req.Algorithm)
+		result.Code = 400
+		return result
+	}
+	if err != nil {
+		result.Message = pepperMsg
+		result.Code = 500
+		return result
+	}
+
+	// The password would otherwise only be rejected once the task runs
+	limiter, limited := hasher.(passwordLimiter)
+	if limited && len(req.Password) > limiter.MaxPasswordLength() {
+		result.Message = fmt.Sprintf("Password is longer than %v bytes", limiter.MaxPasswordLength())
+		result.Code = 400
+		return result
 	}
 
 	tm.mutex.Lock()
-	defer tm.mutex.Unlock()
+	sequence := tm.taskID + 1
+	id, err := tm.ids.next(sequence)
+	if err == nil {
+		tm.taskID = sequence
+	}
+	tm.mutex.Unlock()
+
+	if err != nil {
+		result.Message = "Failed to create the hash ID"
+		result.Code = 500
+		return result
+	}
+
+	task := &task{hasher: hasher, duration: time.Duration(conf.MaxTaskSeconds) * time.Second}
+	task.ID = id
+	task.Algorithm = hasher.Name()
+	task.Policy = policy
+	task.Created = time.Now()
+
+	// The store is written without holding the mutex so the other calls
+	// do not wait for the disk
+	err = tm.store.Accept(task.Task, req.Password)
+	if err != nil {
+		tm.mutex.Lock()
+		tm.releaseID(sequence)
+		tm.mutex.Unlock()
+		result.Message = storeMsg
+		result.Code = 500
+		return result
+	}
+
+	tm.mutex.Lock()
+
+	// A concurrent request with the same key might have created the task
+	if idempotent {
+		original, replayed := tm.replay(req.IdempotencyKey, fingerprint, time.Now())
+		if replayed {
+			tm.releaseID(sequence)
+			tm.mutex.Unlock()
+			tm.store.Delete(task.ID)
+			return original
+		}
+	}
 
-	tm.taskID++
-	task := &task{ID: tm.taskID, Password: pwd}
 	tm.tasks[task.ID] = task
 
-	tm.wg.Add(1)
-	go tm.runTask(task)
+	// The password is only kept by the job and never by the task
+	password := req.Password
+	job, submitted := tm.submit(func() { tm.runTask(task, password) })
+	if !submitted {
+		delete(tm.tasks, task.ID)
+		tm.releaseID(sequence)
+		result = tm.queueFull()
+		tm.mutex.Unlock()
+		tm.store.Delete(task.ID)
+		return result
+	}
+	task.job = job
+	queued = true
 
 	result.Code = 201
-	result.Message = strconv.FormatUint(tm.taskID, 10)
+	result.Message = task.ID
+
+	if idempotent {
+		tm.remember(req.IdempotencyKey, fingerprint, result, time.Now())
+	}
+
+	tm.mutex.Unlock()
 	return result
 }
 
-// Check Checks if a new password hash task has completed. This call
-// might fail if shutdown is pending.
+// releaseID Gives back the sequence of a task that was not created if no
+// other task has been created since. The caller must hold the mutex.
+func (tm *Manager) releaseID(sequence uint64) {
+	if tm.taskID == sequence {
+		tm.taskID--
+	}
+}
+
+// Check Checks if a new password hash task has completed. Completed tasks
+// that have been evicted are reported with status 410. This call might fail
+// if shutdown is pending.
 func (tm *Manager) Check(hashID string) Result {
 	result := Result{}
 
 	if tm.done {
 		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
 		result.Code = 500
 		return result
 	}
 
-	id, err := strconv.ParseUint(hashID, 10, 64)
-	if err != nil {
-		result.Message = fmt.Sprintf("Invalid hash ID %v", hashID)
-		result.Code = 400
-		return result
-	}
-
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
-	task, exists := tm.tasks[id]
-	if !exists {
-		result.Message = fmt.Sprintf("No such hash ID %v", hashID)
-		result.Code = 404
+	task, result := tm.findTask(hashID)
+	if task == nil {
 		return result
 	}
 
@@ -162,36 +504,649 @@ func (tm *Manager) Check(hashID string) Result {
 		return result
 	}
 
+	tm.completed.MoveToFront(task.element)
+
+	if task.Err != "" {
+		result.Message = fmt.Sprintf("Failed to hash password: %v", task.Err)
+		result.Code = 500
+		return result
+	}
+
 	result.Code = 200
 	result.Message = task.Hash
 
 	return result
 }
 
-func (tm *Manager) runTask(task *task) {
-	defer tm.wg.Done()
+// Status Returns the state of the task. The returned result is the same one
+// Check returns so the state can be sent with the same status code. The
+// result also has the number of seconds to wait if the task is not done.
+// This call might fail if shutdown is pending or the hash ID is invalid.
+func (tm *Manager) Status(hashID string) (TaskStatus, Result) {
+	status := TaskStatus{}
+	result := Result{}
+
+	if tm.done {
+		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
+		result.Code = 500
+		return status, result
+	}
+
+	tm.mutex.Lock()
+	task, result := tm.findTask(hashID)
+	if task == nil {
+		tm.mutex.Unlock()
+		return status, result
+	}
+	record, started, job, delay := task.Task, task.started, task.job, task.duration
+	hashTime := tm.expectedHashTime()
+	if record.Done {
+		tm.completed.MoveToFront(task.element)
+	}
+	tm.mutex.Unlock()
+
+	status.ID = record.ID
+	status.Algorithm = record.Algorithm
+	status.Policy = record.Policy
+	status.Created = record.Created.UTC()
+
+	if !record.Done {
+		remaining, running := tm.remaining(started, job, delay, hashTime)
+		estimate := time.Now().Add(remaining).UTC()
+
+		status.Status = StatusPending
+		if running {
+			status.Status = StatusRunning
+		}
+		status.EstimatedCompletion = &estimate
+
+		result.Code = 503
+		result.RetryAfter = RetryAfter(remaining)
+		return status, result
+	}
+
+	completed := record.Completed.UTC()
+	status.Completed = &completed
+
+	if record.Err != "" {
+		status.Status = StatusFailed
+		status.Error = record.Err
+		result.Message = fmt.Sprintf("Failed to hash password: %v", record.Err)
+		result.Code = 500
+		return status, result
+	}
+
+	status.Status = StatusDone
+	status.Hash = record.Hash
+	result.Code = 200
+	result.Message = record.Hash
+
+	return status, result
+}
+
+// Verify Verifies that the password matches the encoded hash. The
+// algorithm is detected from the encoded hash. If rehash is true and
+// the password matches an outdated hash, a replacement hash is created.
+// The password is zeroed once the verification is done.
+// The verification runs as a task so it is accounted for like any other
+// hash task. This call blocks until the verification is done and might
+// fail if shutdown is pending or the hash cannot be decoded.
+func (tm *Manager) Verify(pwd []byte, encoded string, rehash bool) (Verification, Result) {
+	verification := Verification{}
+	result := Result{}
+
+	defer zero(pwd)
+
+	if tm.done {
+		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
+		result.Code = 500
+		return verification, result
+	}
+
+	hasher, err := IdentifyHasher(encoded, tm.conf())
+	if errors.Is(err, ErrUnknownAlgorithm) {
+		result.Message = "Unknown hash format"
+		result.Code = 400
+		return verification, result
+	}
+	if errors.Is(err, ErrPepperNotConfigured) {
+		result.Message = "Peppered hashes are not accepted"
+		result.Code = 400
+		return verification, result
+	}
+	if err != nil {
+		result.Message = pepperMsg
+		result.Code = 500
+		return verification, result
+	}
+
+	// The parameters of the hash are checked before it runs on a worker
+	_, err = hasher.NeedsRehash(encoded)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid %v hash", hasher.Name())
+		result.Code = 400
+		return verification, result
+	}
+
+	done := make(chan struct{})
+	_, submitted := tm.submit(func() {
+		defer close(done)
+		verification.Match, err = hasher.Verify(pwd, encoded)
+		if err != nil || !verification.Match || !rehash {
+			return
+		}
+		verification.Hash, err = tm.rehash(pwd, encoded)
+	})
+	if !submitted {
+		tm.mutex.Lock()
+		defer tm.mutex.Unlock()
+		return verification, tm.queueFull()
+	}
+	<-done
+
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid %v hash", hasher.Name())
+		result.Code = 400
+		return verification, result
+	}
+
+	result.Code = 200
+	return verification, result
+}
+
+// NeedsRehash Checks if the encoded hash was created with the configured
+// algorithm and parameters. This call might fail if shutdown is pending
+// or the hash cannot be decoded.
+func (tm *Manager) NeedsRehash(encoded string) (RehashCheck, Result) {
+	check := RehashCheck{}
+	result := Result{}
+
+	if tm.done {
+		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
+		result.Code = 500
+		return check, result
+	}
+
+	outdated, err := NeedsRehash(tm.conf(), encoded)
+	if err != nil {
+		result.Message = "Invalid hash"
+		result.Code = 400
+		return check, result
+	}
+
+	check.NeedsRehash = outdated
+	result.Code = 200
+	return check, result
+}
+
+// rehash Creates a new hash with the configured algorithm if the
+// encoded hash is outdated. The algorithm of the hash is kept if the
+// configured one is weaker. An empty hash is returned otherwise.
+func (tm *Manager) rehash(pwd []byte, encoded string) (string, error) {
+	conf := tm.conf()
+	outdated, err := NeedsRehash(conf, encoded)
+	if err != nil || !outdated {
+		return "", err
+	}
+
+	current, err := IdentifyHasher(encoded, conf)
+	if err != nil {
+		return "", err
+	}
+
+	hasher, err := NewHasher(rehashAlgorithm(conf, current.Name()), conf)
+	if err != nil {
+		return "", err
+	}
+
+	return hasher.Hash(pwd)
+}
+
+// EstimateCompletion Estimates how long it will take for the task to be
+// done. The estimate is based on the task's position in the queue, the
+// number of workers, the moving average of the recent hash durations, and
+// the task duration. A task waiting in the queue starts once the tasks
+// ahead of it are hashed since a worker is not used during the task
+// duration. This call might fail if shutdown is pending or the hash ID is
+// invalid.
+func (tm *Manager) EstimateCompletion(hashID string) (time.Duration, Result) {
+	result := Result{}
+
+	if tm.done {
+		result.Message = shutdownMsg
+		result.Problem = ProblemShutdown
+		result.Code = 500
+		return 0, result
+	}
+
+	tm.mutex.Lock()
+	task, result := tm.findTask(hashID)
+	if task == nil {
+		tm.mutex.Unlock()
+		return 0, result
+	}
+	done, started, job, delay := task.Done, task.started, task.job, task.duration
+	hashTime := tm.expectedHashTime()
+	tm.mutex.Unlock()
+
+	result.Code = 200
+
+	if done {
+		return 0, result
+	}
+
+	remaining, _ := tm.remaining(started, job, delay, hashTime)
+	return remaining, result
+}
+
+// remaining Estimates how long it will take for a task that is not done
+// to be done given its task duration and the expected hash duration. A
+// task is done once it is hashed and its task duration has passed. This
+// call returns true if the task is running.
+func (tm *Manager) remaining(started time.Time, job *job, delay, hashTime time.Duration) (time.Duration, bool) {
+	// The task is running
+	position := tm.pool.position(job)
+	if position < 0 {
+		remaining := hashTime + delay
+		if !started.IsZero() {
+			remaining -= time.Since(started)
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		return remaining, true
+	}
+
+	// Every worker is busy so the task starts after the running tasks
+	// and the tasks ahead of it in the queue are hashed.
+	rounds := time.Duration(position/tm.pool.workers + 1)
+	return rounds*hashTime + hashTime + delay, false
+}
+
+// findTask Returns the task identified by hashID. If the task cannot be
+// found, nil is returned along with the result to send. Tasks that have
+// been evicted are reported with status 410. The caller must hold the mutex.
+func (tm *Manager) findTask(hashID string) (*task, Result) {
+	result := Result{}
+
+	if !tm.ids.valid(hashID) {
+		result.Message = fmt.Sprintf("Invalid hash ID %v", hashID)
+		result.Code = 400
+		return nil, result
+	}
+
+	task, exists := tm.tasks[hashID]
+	if exists {
+		return task, result
+	}
+
+	if tm.expired(hashID) {
+		result.Message = fmt.Sprintf("Hash ID %v has expired", hashID)
+		result.Code = 410
+		return nil, result
+	}
+
+	result.Message = fmt.Sprintf("No such hash ID %v", hashID)
+	result.Problem = ProblemUnknownID
+	result.Code = 404
+	return nil, result
+}
+
+// expired Returns true if the task with the ID existed but has been
+// evicted. The caller must hold the mutex.
+func (tm *Manager) expired(hashID string) bool {
+	_, evicted := tm.evicted[hashID]
+	if evicted {
+		return true
+	}
+
+	// Sequential IDs are handed out in order so any ID up to the last one
+	// that is not in the map has been evicted. This holds even for the
+	// tasks evicted before a restart.
+	if _, sequential := tm.ids.(sequentialIDs); sequential {
+		id, _ := strconv.ParseUint(hashID, 10, 64)
+		return id > 0 && id <= tm.taskID
+	}
+
+	return false
+}
+
+// RetryAfter Converts an estimate to the number of seconds used in
+// the Retry-After header. The seconds are rounded up and are never
+// less than one.
+func RetryAfter(estimate time.Duration) uint {
+	seconds := uint((estimate + time.Second - 1) / time.Second)
+	if seconds == 0 {
+		return 1
+	}
+	return seconds
+}
+
+// submit Queues the job to be run by the worker pool. WaitForPendingTasks
+// waits for all the submitted jobs. This call returns false if the queue is full.
+func (tm *Manager) submit(run func()) (*job, bool) {
+	tm.wg.Add(1)
+	job, submitted := tm.pool.submit(func() {
+		defer tm.wg.Done()
+		run()
+	})
+	if !submitted {
+		tm.wg.Done()
+	}
+	return job, submitted
+}
+
+// queueFull Returns the result used to reject new jobs when the queue
+// is full. The client is asked to retry once a queue slot is expected
+// to be free, which is when the next running task is hashed. The caller
+// must hold the mutex.
+func (tm *Manager) queueFull() Result {
+	result := Result{}
+	result.Message = queueFullMsg
+	result.Problem = ProblemQueueFull
+	result.Code = 503
+
+	hashTime := tm.expectedHashTime()
+	result.RetryAfter = RetryAfter(hashTime / time.Duration(tm.pool.workers))
+	return result
+}
+
+// expectedHashTime Returns the moving average of the recent hash durations,
+// which is how long a worker is busy with a task. The default calibration
+// target is used until a password is hashed. The caller must hold the mutex.
+func (tm *Manager) expectedHashTime() time.Duration {
+	if tm.hashedTasks == 0 {
+		return defaultHashTime
+	}
+	return tm.recentHashTime
+}
 
+// runTask Hashes the password on a worker. The task is only done once the
+// configured task duration has also passed. The worker is released while
+// waiting so it can run other jobs.
+func (tm *Manager) runTask(task *task, password []byte) {
 	start := time.Now()
-	hash := sha512.Sum512([]byte(task.Password))
-	data := base64.StdEncoding.EncodeToString(hash[:])
-	time.Sleep(time.Second * time.Duration(tm.config.MaxTaskSeconds))
-	taskDuration := time.Since(start)
 
 	tm.mutex.Lock()
-	defer tm.mutex.Unlock()
+	task.started = start
+	tm.mutex.Unlock()
+
+	data, err := task.hasher.Hash(password)
+	zero(password)
+	hashTime := time.Since(start)
+
+	tm.mutex.Lock()
+	if tm.hashedTasks == 0 {
+		tm.recentHashTime = hashTime
+	} else {
+		tm.recentHashTime += (hashTime - tm.recentHashTime) / runtimeSmoothing
+	}
+	tm.hashedTasks++
+	tm.mutex.Unlock()
+
+	// WaitForPendingTasks waits until the task is done
+	tm.wg.Add(1)
+	time.AfterFunc(task.duration, func() {
+		defer tm.wg.Done()
+		tm.completeTask(task, start, data, err)
+	})
+}
+
+// completeTask Marks the task as done with the hash or the error.
+func (tm *Manager) completeTask(task *task, start time.Time, data string, err error) {
+	taskDuration := time.Since(start)
 
+	// Only this call changes the state of a running task
+	record := task.Task
+	record.Hash = data
+	if err != nil {
+		record.Err = err.Error()
+	}
+	record.Done = true
+	record.Completed = time.Now()
+
+	// The store is written before the task is done so the task cannot be
+	// evicted before it is stored. The task stays available until it is
+	// evicted even if it cannot be stored. It is hashed again after a
+	// restart in that case.
+	tm.store.Update(record)
+
+	tm.mutex.Lock()
 	tm.taskRuntime += taskDuration
 	tm.completedTasks++
 
-	task.Hash = data
+	task.Task = record
+	task.element = tm.completed.PushFront(task)
+
+	evicted := tm.evictLeastRecentlyUsed()
+	tm.mutex.Unlock()
+
+	tm.store.Delete(evicted...)
+}
+
+// recover Loads the tasks kept in the store. The completed tasks are
+// ordered by completion time and the tasks that were never completed
+// are queued again even if the queue is full.
+func (tm *Manager) recover() error {
+	tasks, passwords, err := tm.store.Recover()
+	if err != nil {
+		return fmt.Errorf("task: Failed to recover tasks: %v", err)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Completed.Before(tasks[j].Completed)
+	})
+
+	// The tasks that cannot be hashed again are failed and stored before
+	// they are added so the store is not written while holding the mutex
+	conf := tm.conf()
+	recovered := make([]*task, len(tasks))
+	for i, record := range tasks {
+		task := &task{Task: record}
+		recovered[i] = task
+		if task.Done {
+			continue
+		}
+
+		password, exists := passwords[task.ID]
+		if !exists {
+			tm.abort(task, "Task was interrupted")
+			continue
+		}
+
+		task.hasher, err = NewHasher(task.Algorithm, conf)
+		task.duration = time.Duration(conf.MaxTaskSeconds) * time.Second
+		if err != nil {
+			zero(password)
+			tm.abort(task, err.Error())
+		}
+	}
+
+	tm.mutex.Lock()
+	for _, task := range recovered {
+		tm.tasks[task.ID] = task
+
+		// Only sequential IDs can be parsed. The task count is
+		// used as the sequence of the other schemes.
+		id, err := strconv.ParseUint(task.ID, 10, 64)
+		if err != nil {
+			id = tm.taskID + 1
+		}
+		if id > tm.taskID {
+			tm.taskID = id
+		}
+
+		if task.Done {
+			task.element = tm.completed.PushFront(task)
+			continue
+		}
+
+		task, password := task, passwords[task.ID]
+		tm.wg.Add(1)
+		task.job = tm.pool.resume(func() {
+			defer tm.wg.Done()
+			tm.runTask(task, password)
+		})
+	}
+
+	evicted := tm.evictLeastRecentlyUsed()
+	tm.mutex.Unlock()
+
+	tm.store.Delete(evicted...)
+	return nil
+}
+
+// abort Fails and stores a recovered task that cannot be hashed again.
+// The task must not have been added to the task manager yet.
+func (tm *Manager) abort(task *task, reason string) {
+	task.Err = reason
 	task.Done = true
+	task.Completed = time.Now()
+	tm.store.Update(task.Task)
+}
+
+// janitor Periodically evicts the completed tasks that have expired
+// until the task manager is shutdown.
+func (tm *Manager) janitor() {
+	retention := time.Duration(tm.conf().TaskRetentionSeconds) * time.Second
+	interval := retention / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-tm.stopJanitor:
+			return
+		case now := <-ticker.C:
+			tm.mutex.Lock()
+			evicted := tm.evictExpired(now)
+			tm.mutex.Unlock()
+
+			tm.store.Delete(evicted...)
+		}
+	}
+}
+
+// evictExpired Evicts the completed tasks that have been completed for
+// longer than the retention time. The IDs of the evicted tasks are
+// returned so the caller can delete them from the store once the mutex
+// is released. The caller must hold the mutex.
+func (tm *Manager) evictExpired(now time.Time) []string {
+	retention := time.Duration(tm.conf().TaskRetentionSeconds) * time.Second
+
+	var evicted []string
+	for element := tm.completed.Front(); element != nil; {
+		next := element.Next()
+		task := element.Value.(*task)
+		if now.Sub(task.Completed) >= retention {
+			tm.evict(task)
+			evicted = append(evicted, task.ID)
+		}
+		element = next
+	}
+
+	return evicted
+}
+
+// evictLeastRecentlyUsed Evicts the least recently used completed tasks
+// until there are no more than the maximum. The IDs of the evicted tasks
+// are returned like evictExpired. The caller must hold the mutex.
+func (tm *Manager) evictLeastRecentlyUsed() []string {
+	max := int(tm.conf().MaxCompletedTasks)
+	if max == 0 {
+		return nil
+	}
+
+	var evicted []string
+	for tm.completed.Len() > max {
+		task := tm.completed.Back().Value.(*task)
+		tm.evict(task)
+		evicted = append(evicted, task.ID)
+	}
+
+	return evicted
 }
 
-const shutdownMsg = "Service is shutting down"
+// evict Removes the completed task from memory. The task must also be
+// deleted from the store. The caller must hold the mutex.
+func (tm *Manager) evict(task *task) {
+	tm.completed.Remove(task.element)
+	delete(tm.tasks, task.ID)
+
+	tm.evicted[task.ID] = struct{}{}
+	tm.evictedOrder.PushBack(task.ID)
+	if tm.evictedOrder.Len() > maxEvictedIDs {
+		oldest := tm.evictedOrder.Remove(tm.evictedOrder.Front())
+		delete(tm.evicted, oldest.(string))
+	}
+}
+
+// zero Overwrites the password so it does not linger in memory.
+func zero(password []byte) {
+	for i := range password {
+		password[i] = 0
+	}
+}
+
+const (
+	// StatusPending The task is waiting for a worker.
+	StatusPending = "pending"
+	// StatusRunning The password is being hashed.
+	StatusRunning = "running"
+	// StatusDone The password has been hashed.
+	StatusDone = "done"
+	// StatusFailed The password could not be hashed.
+	StatusFailed = "failed"
+)
+
+const (
+	// ProblemWeakPassword The password does not meet the strength requirements.
+	ProblemWeakPassword = "weak-password"
+	// ProblemShutdown The service is shutting down.
+	ProblemShutdown = "shutdown"
+	// ProblemUnknownID The hash ID does not exist.
+	ProblemUnknownID = "unknown-id"
+	// ProblemQueueFull There are too many pending tasks.
+	ProblemQueueFull = "queue-full"
+)
+
+const (
+	shutdownMsg  = "Service is shutting down"
+	pepperMsg    = "Failed to load the pepper"
+	blocklistMsg = "Failed to check the breached passwords"
+	queueFullMsg = "Too many pending tasks"
+	storeMsg     = "Failed to store the task"
+
+	defaultWorkers   = 64
+	defaultQueueSize = 10000
+
+	// maxEvictedIDs The number of evicted IDs remembered to report
+	// them as expired instead of unknown.
+	maxEvictedIDs = 100000
+
+	// runtimeSmoothing The weight of the previous tasks in the moving
+	// average of the hash durations.
+	runtimeSmoothing = 5
+
+	// defaultHashTime The expected hash duration until a password is
+	// hashed. It is the default target of the calibrate command.
+	defaultHashTime = 250 * time.Millisecond
+)
 
+// task The stored task along with the state that is only
+// needed while the task manager is running.
 type task struct {
-	ID       uint64
-	Done     bool
-	Hash     string
-	Password string
+	Task
+	hasher   Hasher
+	duration time.Duration
+	job      *job
+	started  time.Time
+	element  *list.Element
 }
@@ -2,10 +2,15 @@ package task
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/strength"
 )
 
 func TestManager_ShutdownTwice(t *testing.T) {
@@ -23,6 +28,33 @@ func TestManager_ShutdownTwice(t *testing.T) {
 	}
 }
 
+func TestManager_ShutdownConcurrent(t *testing.T) {
+	conf := config.Config{}
+	mgr := NewManager(conf)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- mgr.Shutdown().Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	succeeded := 0
+	for code := range codes {
+		if code == 200 {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("Shutdown should only succeed once, not %v times", succeeded)
+	}
+}
+
 func TestManager_Stats(t *testing.T) {
 	conf := config.Config{}
 	mgr := NewManager(conf)
@@ -77,7 +109,7 @@ func TestManager_ShutdownNewTask(t *testing.T) {
 		t.Errorf("Shutdown should return 200, not %v", res.Code)
 	}
 
-	res = mgr.NewTask("pass")
+	res = mgr.NewTask(Request{Password: []byte("pass")})
 	if res.Code != 500 {
 		t.Errorf("Stats should return 500, not %v", res.Code)
 	}
@@ -90,10 +122,131 @@ func TestManager_NewTaskPasswordStrength(t *testing.T) {
 
 	mgr := NewManager(conf)
 
-	res := mgr.NewTask("pass")
+	res := mgr.NewTask(Request{Password: []byte("pass")})
 	if res.Code != 400 {
 		t.Errorf("Stats should return 400, not %v", res.Code)
 	}
+
+	if res.Strength == nil || res.Strength.Passed {
+		t.Errorf("NewTask should return the strength report: %+v", res)
+	}
+}
+
+func TestManager_NewTaskPolicy(t *testing.T) {
+	conf := config.Config{}
+	conf.CheckPasswordStrength = true
+	conf.PasswordStrength.MinLength = 8
+	conf.PasswordStrength.MaxLength = 50
+	conf.PasswordPolicies = map[string]strength.PasswordStrength{
+		"admin": {MinLength: 16, MaxLength: 50},
+	}
+
+	mgr := NewManager(conf)
+
+	res := mgr.NewTask(Request{Password: []byte("password"), Policy: "admin"})
+	if res.Code != 400 || res.Strength == nil || res.Strength.Violations[0].Rule != strength.RuleMinLength {
+		t.Errorf("NewTask should enforce the admin policy: %+v", res)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("password"), Policy: "unknown"})
+	if res.Code != 400 {
+		t.Errorf("NewTask should return 400 for an unknown policy, not %v", res.Code)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("passwordpassword"), Policy: "admin"})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+	status, _ := mgr.Status(res.Message)
+	if status.Policy != "admin" {
+		t.Errorf("NewTask should record the admin policy, not %q", status.Policy)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("password")})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+	status, _ = mgr.Status(res.Message)
+	if status.Policy != config.DefaultPolicyName {
+		t.Errorf("NewTask should record the default policy, not %q", status.Policy)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_Reload(t *testing.T) {
+	conf := config.Config{}
+	conf.MaxTaskSeconds = 1
+	conf.ServerAddress = ":8080"
+	conf.PasswordStrength.MaxLength = 50
+
+	mgr := NewManager(conf)
+
+	res := mgr.NewTask(Request{Password: []byte("password")})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+	hashID := res.Message
+
+	next := conf
+	next.CheckPasswordStrength = true
+	next.PasswordStrength.MinLength = 12
+	next.MaxTaskSeconds = 0
+	next.ServerAddress = ":9090"
+
+	restart := mgr.Reload(next)
+	if len(restart) != 1 || restart[0] != "ServerAddress" {
+		t.Errorf("Reload should return ServerAddress, not %v", restart)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("password")})
+	if res.Code != 400 {
+		t.Errorf("NewTask should enforce the reloaded policy, not %v", res.Code)
+	}
+
+	mgr.WaitForPendingTasks()
+
+	res = mgr.Check(hashID)
+	if res.Code != 200 {
+		t.Errorf("The task in progress should complete after Reload, not %v", res.Code)
+	}
+}
+
+func TestManager_NewTaskBreachedPassword(t *testing.T) {
+	conf := config.Config{}
+	conf.BreachFile = filepath.Join(t.TempDir(), "breached.txt")
+	conf.BreachThreshold = 10
+
+	// The SHA-1 hashes of password and 123456
+	content := "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n" +
+		"7C4A8D09CA3762AF61E59520943DC26494F8941B:5\n"
+	ioutil.WriteFile(conf.BreachFile, []byte(content), 0600)
+
+	mgr := NewManager(conf)
+	defer mgr.Close()
+
+	res := mgr.NewTask(Request{Password: []byte("password")})
+	if res.Code != 400 || res.Problem != ProblemWeakPassword {
+		t.Errorf("NewTask should return 400, not %v", res.Code)
+	}
+
+	violation := strength.Violation{Rule: strength.RuleBreached, Required: 10, Actual: 9545824}
+	if res.Strength == nil || len(res.Strength.Violations) != 1 || res.Strength.Violations[0] != violation {
+		t.Errorf("NewTask should return the breached violation: %+v", res.Strength)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("123456")})
+	if res.Code != 201 {
+		t.Errorf("NewTask should allow a password below the threshold, not %v", res.Code)
+	}
+
+	conf.BreachFile = filepath.Join(t.TempDir(), "missing.txt")
+	res = NewManager(conf).NewTask(Request{Password: []byte("password")})
+	if res.Code != 500 {
+		t.Errorf("NewTask should return 500 without the blocklist, not %v", res.Code)
+	}
+
+	mgr.WaitForPendingTasks()
 }
 
 func TestManager_WaitForTasks(t *testing.T) {
@@ -108,7 +261,7 @@ func TestManager_WaitForTasks(t *testing.T) {
 
 	for i := 0; i < 50; i++ {
 		pass := fmt.Sprintf("pass%v", i)
-		res := mgr.NewTask(pass)
+		res := mgr.NewTask(Request{Password: []byte(pass)})
 		if res.Code != 201 {
 			t.Errorf("Stats should return 201, not %v", res.Code)
 			continue
@@ -138,3 +291,521 @@ func TestManager_WaitForTasks(t *testing.T) {
 
 	mgr.WaitForPendingTasks()
 }
+
+func TestManager_NewTaskUnknownAlgorithm(t *testing.T) {
+	conf := config.Config{}
+	mgr := NewManager(conf)
+
+	res := mgr.NewTask(Request{Password: []byte("pass"), Algorithm: "md5"})
+	if res.Code != 400 {
+		t.Errorf("NewTask should return 400, not %v", res.Code)
+	}
+}
+
+func TestManager_NewTaskAlgorithm(t *testing.T) {
+	conf := config.Config{}
+	conf.HashAlgorithm = Bcrypt
+	mgr := NewManager(conf)
+
+	res := mgr.NewTask(Request{Password: []byte("pass"), Algorithm: PBKDF2SHA256})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+		return
+	}
+
+	mgr.WaitForPendingTasks()
+
+	res = mgr.Check(res.Message)
+	if res.Code != 200 {
+		t.Errorf("Check should return 200, not %v", res.Code)
+	}
+	if !strings.HasPrefix(res.Message, "$pbkdf2-sha256$") {
+		t.Errorf("Hash should use pbkdf2-sha256: %v", res.Message)
+	}
+}
+
+func TestManager_NewTaskBcryptLongPassword(t *testing.T) {
+	conf := config.Config{}
+	conf.Bcrypt.Cost = 4
+	mgr := NewManager(conf)
+
+	res := mgr.NewTask(Request{Password: []byte(strings.Repeat("é", 37)), Algorithm: Bcrypt})
+	if res.Code != 400 {
+		t.Errorf("NewTask should return 400 for a password longer than 72 bytes, not %v", res.Code)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte(strings.Repeat("a", 72)), Algorithm: Bcrypt})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_Verify(t *testing.T) {
+	conf := config.Config{}
+	mgr := NewManager(conf)
+
+	hasher, _ := NewHasher(Bcrypt, conf)
+	hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+	verification, res := mgr.Verify([]byte("angryMonkey"), hash, false)
+	if res.Code != 200 || !verification.Match {
+		t.Errorf("Verify should match: %+v %+v", res, verification)
+	}
+
+	verification, res = mgr.Verify([]byte("happyMonkey"), hash, false)
+	if res.Code != 200 || verification.Match {
+		t.Errorf("Verify should not match: %+v %+v", res, verification)
+	}
+
+	_, res = mgr.Verify([]byte("angryMonkey"), "$md5$abc", false)
+	if res.Code != 400 {
+		t.Errorf("Verify should return 400, not %v", res.Code)
+	}
+
+	_, res = mgr.Verify([]byte("angryMonkey"), "$2a$10$short", false)
+	if res.Code != 400 {
+		t.Errorf("Verify should return 400, not %v", res.Code)
+	}
+
+	_, res = mgr.Verify([]byte("angryMonkey"), "$pepper$v=1"+hash, false)
+	if res.Code != 400 {
+		t.Errorf("Verify should return 400 without a pepper file, not %v", res.Code)
+	}
+
+	_, res = mgr.Verify([]byte("angryMonkey"), "$argon2id$v=19$m=4294967295,t=4294967295,p=255$c2FsdA$aGFzaA", false)
+	if res.Code != 400 {
+		t.Errorf("Verify should return 400 for hostile parameters, not %v", res.Code)
+	}
+
+	mgr.Shutdown()
+	_, res = mgr.Verify([]byte("angryMonkey"), hash, false)
+	if res.Code != 500 {
+		t.Errorf("Verify should return 500, not %v", res.Code)
+	}
+}
+
+func TestManager_VerifyRehash(t *testing.T) {
+	conf := config.Config{}
+	conf.HashAlgorithm = Argon2id
+	mgr := NewManager(conf)
+
+	hasher, _ := NewHasher(SHA512, conf)
+	hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+	verification, res := mgr.Verify([]byte("angryMonkey"), hash, true)
+	if res.Code != 200 || !verification.Match {
+		t.Errorf("Verify should match: %+v %+v", res, verification)
+	}
+	if !strings.HasPrefix(verification.Hash, "$argon2id$") {
+		t.Errorf("Verify should rehash with argon2id: %v", verification.Hash)
+	}
+
+	verification, res = mgr.Verify([]byte("angryMonkey"), verification.Hash, true)
+	if res.Code != 200 || !verification.Match || verification.Hash != "" {
+		t.Errorf("Verify should not rehash: %+v %+v", res, verification)
+	}
+
+	verification, _ = mgr.Verify([]byte("happyMonkey"), hash, true)
+	if verification.Hash != "" {
+		t.Errorf("Verify should not rehash a mismatch: %+v", verification)
+	}
+
+	// The default SHA512 algorithm never replaces a stronger hash
+	mgr = NewManager(config.Config{})
+	hasher, _ = NewHasher(Bcrypt, conf)
+	hash, _ = hasher.Hash([]byte("angryMonkey"))
+
+	verification, res = mgr.Verify([]byte("angryMonkey"), hash, true)
+	if res.Code != 200 || !verification.Match || verification.Hash != "" {
+		t.Errorf("Verify should not rehash bcrypt with sha512: %+v %+v", res, verification)
+	}
+}
+
+func TestManager_NeedsRehash(t *testing.T) {
+	conf := config.Config{}
+	conf.HashAlgorithm = Bcrypt
+	mgr := NewManager(conf)
+
+	hasher, _ := NewHasher(Bcrypt, conf)
+	hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+	check, res := mgr.NeedsRehash(hash)
+	if res.Code != 200 || check.NeedsRehash {
+		t.Errorf("NeedsRehash should be false: %+v %+v", res, check)
+	}
+
+	_, res = mgr.NeedsRehash("$md5$abc")
+	if res.Code != 400 {
+		t.Errorf("NeedsRehash should return 400, not %v", res.Code)
+	}
+
+	mgr.Shutdown()
+	_, res = mgr.NeedsRehash(hash)
+	if res.Code != 500 {
+		t.Errorf("NeedsRehash should return 500, not %v", res.Code)
+	}
+}
+
+func TestManager_NewTaskQueueFull(t *testing.T) {
+	conf := config.Config{}
+	conf.MaxTaskSeconds = 1
+	conf.Workers = 1
+	conf.QueueSize = 1
+	mgr := NewManager(conf)
+
+	res := mgr.NewTask(Request{Password: []byte("pass1")})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+
+	// Wait for the worker to take the first task
+	for mgr.pool.queued() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("pass2")})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+
+	res = mgr.NewTask(Request{Password: []byte("pass3")})
+	// A slot is expected to be free once the running task is done
+	if res.Code != 503 || res.RetryAfter != 1 {
+		t.Errorf("NewTask should return 503 with Retry-After 1, not %+v", res)
+	}
+
+	// The rejected task should not be visible
+	res = mgr.Check("3")
+	if res.Code != 404 {
+		t.Errorf("Check should return 404, not %v", res.Code)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_EstimateCompletion(t *testing.T) {
+	conf := config.Config{}
+	conf.MaxTaskSeconds = 1
+	conf.Workers = 1
+	mgr := NewManager(conf)
+
+	running := mgr.NewTask(Request{Password: []byte("pass1")})
+
+	// Keep the worker busy once the first task is hashed
+	busy := make(chan struct{})
+	release := make(chan struct{})
+	for mgr.pool.queued() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	mgr.submit(func() { close(busy); <-release })
+	<-busy
+
+	var queued Result
+	for i := 0; i < 10; i++ {
+		queued = mgr.NewTask(Request{Password: []byte(fmt.Sprintf("queued%v", i))})
+	}
+
+	estimate, res := mgr.EstimateCompletion(running.Message)
+	if res.Code != 200 || estimate > time.Second {
+		t.Errorf("Running task estimate should be at most 1s: %v %+v", estimate, res)
+	}
+
+	// The last queued task waits for the tasks ahead of it to be hashed,
+	// not for their task duration, so it is done about 1s after it starts
+	estimate, res = mgr.EstimateCompletion(queued.Message)
+	if res.Code != 200 || estimate < time.Second || estimate > 1500*time.Millisecond {
+		t.Errorf("Queued task estimate should be about 1s: %v %+v", estimate, res)
+	}
+
+	_, res = mgr.EstimateCompletion("100")
+	if res.Code != 404 {
+		t.Errorf("EstimateCompletion should return 404, not %v", res.Code)
+	}
+
+	_, res = mgr.EstimateCompletion("badInteger")
+	if res.Code != 400 {
+		t.Errorf("EstimateCompletion should return 400, not %v", res.Code)
+	}
+
+	close(release)
+	mgr.WaitForPendingTasks()
+
+	estimate, res = mgr.EstimateCompletion(queued.Message)
+	if res.Code != 200 || estimate != 0 {
+		t.Errorf("Done task estimate should be 0: %v %+v", estimate, res)
+	}
+}
+
+func TestManager_WorkerReleased(t *testing.T) {
+	conf := config.Config{}
+	conf.MaxTaskSeconds = 2
+	conf.Workers = 1
+	mgr := NewManager(conf)
+
+	hasher, _ := NewHasher(SHA512, conf)
+	hash, _ := hasher.Hash([]byte("angryMonkey"))
+
+	res := mgr.NewTask(Request{Password: []byte("angryMonkey")})
+	if res.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", res.Code)
+	}
+
+	// The worker is free once the task is hashed
+	start := time.Now()
+	verification, _ := mgr.Verify([]byte("angryMonkey"), hash, false)
+	if !verification.Match || time.Since(start) >= time.Second {
+		t.Errorf("Verify should not wait for the task duration: %v", time.Since(start))
+	}
+
+	res = mgr.Check(res.Message)
+	if res.Code != 503 {
+		t.Errorf("The task should not be done before its duration, not %v", res.Code)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestRetryAfter(t *testing.T) {
+	if RetryAfter(0) != 1 {
+		t.Errorf("RetryAfter should be at least 1 second")
+	}
+	if RetryAfter(1500*time.Millisecond) != 2 {
+		t.Errorf("RetryAfter should round up")
+	}
+}
+
+func TestManager_EvictLeastRecentlyUsed(t *testing.T) {
+	conf := config.Config{}
+	conf.MaxCompletedTasks = 2
+	mgr := NewManager(conf)
+
+	mgr.NewTask(Request{Password: []byte("pass1")})
+	mgr.NewTask(Request{Password: []byte("pass2")})
+	mgr.WaitForPendingTasks()
+
+	// Using the first task makes the second one the least recently used
+	res := mgr.Check("1")
+	if res.Code != 200 {
+		t.Errorf("Check should return 200, not %v", res.Code)
+	}
+
+	mgr.NewTask(Request{Password: []byte("pass3")})
+	mgr.WaitForPendingTasks()
+
+	for hashID, code := range map[string]int{"1": 200, "2": 410, "3": 200, "4": 404} {
+		res = mgr.Check(hashID)
+		if res.Code != code {
+			t.Errorf("Check %v should return %v, not %v", hashID, code, res.Code)
+		}
+	}
+}
+
+func TestManager_EvictExpired(t *testing.T) {
+	conf := config.Config{}
+	conf.TaskRetentionSeconds = 60
+	mgr := NewManager(conf)
+	defer mgr.Shutdown()
+
+	mgr.NewTask(Request{Password: []byte("pass1")})
+	mgr.WaitForPendingTasks()
+
+	mgr.mutex.Lock()
+	mgr.evictExpired(time.Now())
+	mgr.mutex.Unlock()
+
+	res := mgr.Check("1")
+	if res.Code != 200 {
+		t.Errorf("Check should return 200, not %v", res.Code)
+	}
+
+	mgr.mutex.Lock()
+	mgr.evictExpired(time.Now().Add(time.Minute))
+	mgr.mutex.Unlock()
+
+	res = mgr.Check("1")
+	if res.Code != 410 {
+		t.Errorf("Check should return 410, not %v", res.Code)
+	}
+}
+
+func TestManager_Janitor(t *testing.T) {
+	// NOTE: This test might not be determistic
+	// on a system that is low on resources.
+
+	conf := config.Config{}
+	conf.TaskRetentionSeconds = 1
+	mgr := NewManager(conf)
+	defer mgr.Shutdown()
+
+	mgr.NewTask(Request{Password: []byte("pass1")})
+	mgr.WaitForPendingTasks()
+
+	time.Sleep(time.Millisecond * 2500)
+
+	res := mgr.Check("1")
+	if res.Code != 410 {
+		t.Errorf("Check should return 410, not %v", res.Code)
+	}
+}
+
+func TestManager_ScrubPassword(t *testing.T) {
+	conf := config.Config{}
+	mgr := NewManager(conf)
+
+	var passwords [][]byte
+	for _, algorithm := range []string{SHA512, Bcrypt} {
+		password := []byte("angryMonkey")
+		passwords = append(passwords, password)
+
+		res := mgr.NewTask(Request{Password: password, Algorithm: algorithm})
+		if res.Code != 201 {
+			t.Errorf("NewTask should return 201, not %v", res.Code)
+		}
+	}
+
+	mgr.WaitForPendingTasks()
+
+	for _, password := range passwords {
+		if strings.Trim(string(password), "\x00") != "" {
+			t.Errorf("Password should be zeroed: %q", password)
+		}
+	}
+
+	// No completed task should hold the plaintext
+	mgr.mutex.Lock()
+	for _, task := range mgr.tasks {
+		if !task.Done {
+			t.Errorf("Task %v should be done", task.ID)
+		}
+		if strings.Contains(fmt.Sprintf("%+v", *task), "angryMonkey") {
+			t.Errorf("Task %v holds the plaintext password", task.ID)
+		}
+	}
+	mgr.mutex.Unlock()
+}
+
+func TestManager_ScrubRejectedPassword(t *testing.T) {
+	conf := config.Config{}
+	conf.CheckPasswordStrength = true
+	conf.PasswordStrength.MinLength = 20
+	mgr := NewManager(conf)
+
+	password := []byte("angryMonkey")
+	res := mgr.NewTask(Request{Password: password})
+	if res.Code != 400 {
+		t.Errorf("NewTask should return 400, not %v", res.Code)
+	}
+	if strings.Trim(string(password), "\x00") != "" {
+		t.Errorf("Password should be zeroed: %q", password)
+	}
+
+	password = []byte("angryMonkey")
+	mgr.Verify(password, "ZEHhWB65gUlzdVwtDQArEyx+KVLzp/aTaRaPlBzYRIFj6vjFdqEb0Q5B8zVKCZ0vKbZPZklJz0Fd7su2A+gf7Q==", false)
+	if strings.Trim(string(password), "\x00") != "" {
+		t.Errorf("Password should be zeroed: %q", password)
+	}
+}
+
+func TestManager_IdempotencyKey(t *testing.T) {
+	conf := config.Config{}
+	conf.IdempotencyWindowSeconds = 60
+	mgr := NewManager(conf)
+
+	req := func(password, key string) Request {
+		return Request{Password: []byte(password), IdempotencyKey: key}
+	}
+
+	first := mgr.NewTask(req("angryMonkey", "key1"))
+	if first.Code != 201 {
+		t.Errorf("NewTask should return 201, not %v", first.Code)
+	}
+
+	res := mgr.NewTask(req("angryMonkey", "key1"))
+	if res != first {
+		t.Errorf("NewTask should return %+v, not %+v", first, res)
+	}
+
+	res = mgr.NewTask(req("happyMonkey", "key1"))
+	if res.Code != 422 {
+		t.Errorf("NewTask should return 422, not %v", res.Code)
+	}
+
+	res = mgr.NewTask(req("angryMonkey", "key2"))
+	if res.Code != 201 || res.Message != "2" {
+		t.Errorf("NewTask should return 201 with ID 2, not %+v", res)
+	}
+
+	res = mgr.NewTask(req("angryMonkey", strings.Repeat("k", maxIdempotencyKeyLength+1)))
+	if res.Code != 400 {
+		t.Errorf("NewTask should return 400, not %v", res.Code)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_IdempotencyKeyExpired(t *testing.T) {
+	conf := config.Config{}
+	conf.IdempotencyWindowSeconds = 60
+	mgr := NewManager(conf)
+
+	mgr.NewTask(Request{Password: []byte("angryMonkey"), IdempotencyKey: "key1"})
+
+	mgr.mutex.Lock()
+	mgr.evictExpiredKeys(time.Now().Add(time.Minute))
+	mgr.mutex.Unlock()
+
+	res := mgr.NewTask(Request{Password: []byte("happyMonkey"), IdempotencyKey: "key1"})
+	if res.Code != 201 || res.Message != "2" {
+		t.Errorf("NewTask should return 201 with ID 2, not %+v", res)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_IdempotencyKeyDisabled(t *testing.T) {
+	conf := config.Config{}
+	mgr := NewManager(conf)
+
+	mgr.NewTask(Request{Password: []byte("angryMonkey"), IdempotencyKey: "key1"})
+	res := mgr.NewTask(Request{Password: []byte("angryMonkey"), IdempotencyKey: "key1"})
+	if res.Code != 201 || res.Message != "2" {
+		t.Errorf("NewTask should return 201 with ID 2, not %+v", res)
+	}
+
+	mgr.WaitForPendingTasks()
+}
+
+func TestManager_Status(t *testing.T) {
+	conf := config.Config{}
+	conf.MaxTaskSeconds = 1
+	conf.Workers = 1
+	mgr := NewManager(conf)
+
+	mgr.NewTask(Request{Password: []byte("pass1")})
+	mgr.NewTask(Request{Password: []byte("pass2")})
+
+	status, res := mgr.Status("2")
+	if res.Code != 503 || res.RetryAfter == 0 {
+		t.Errorf("Status should return 503 with Retry-After, not %+v", res)
+	}
+	if status.ID != "2" || status.Algorithm != SHA512 || status.EstimatedCompletion == nil {
+		t.Errorf("Status returned %+v", status)
+	}
+
+	mgr.WaitForPendingTasks()
+
+	status, res = mgr.Status("2")
+	if res.Code != 200 || res.Message != status.Hash {
+		t.Errorf("Status should return 200 with the hash, not %+v", res)
+	}
+	if status.Status != StatusDone || status.Completed == nil || status.EstimatedCompletion != nil {
+		t.Errorf("Status returned %+v", status)
+	}
+
+	_, res = mgr.Status("3")
+	if res.Code != 404 {
+		t.Errorf("Status should return 404, not %v", res.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package task
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/strength"
+)
+
+// ValidateConfig Checks the configuration with config.Validate and also
+// checks the values that depend on this package. Every problem is
+// reported at once in config.ValidationErrors.
+func ValidateConfig(conf config.Config) error {
+	errs := config.ValidationErrors{}
+
+	err := conf.Validate()
+	if !errors.As(err, &errs) && err != nil {
+		return err
+	}
+
+	_, err = newHasher(conf.HashAlgorithm, conf)
+	if err != nil {
+		errs.Add("HashAlgorithm", "no hasher is registered as %q", conf.HashAlgorithm)
+	}
+
+	_, err = newIDGenerator(conf.IDScheme)
+	if err != nil {
+		errs.Add("IDScheme", "must be random, uuidv7, ulid, or sequential, not %q", conf.IDScheme)
+	}
+
+	validateLimits(&errs, conf)
+	validateFiles(&errs, conf)
+
+	return errs.Err()
+}
+
+// validateLimits Checks that the hashes created with the configured
+// parameters are within the limits of the hashes that are verified.
+func validateLimits(errs *config.ValidationErrors, conf config.Config) {
+	argon2 := newArgon2Hasher(conf).(argon2Hasher)
+	if argon2.memory > maxArgon2Memory {
+		errs.Add("Argon2.Memory", "must be at most %v KiB, not %v", maxArgon2Memory, argon2.memory)
+	}
+	if argon2.time > maxArgon2Iterations {
+		errs.Add("Argon2.Iterations", "must be at most %v, not %v", maxArgon2Iterations, argon2.time)
+	}
+	if argon2.threads > maxArgon2Parallelism {
+		errs.Add("Argon2.Parallelism", "must be at most %v, not %v", maxArgon2Parallelism, argon2.threads)
+	}
+
+	scrypt := newScryptHasher(conf).(scryptHasher)
+	if scrypt.logN > maxScryptLogN {
+		errs.Add("Scrypt.LogN", "must be at most %v, not %v", maxScryptLogN, scrypt.logN)
+	} else if 128*scrypt.r<<scrypt.logN > maxScryptMemory {
+		errs.Add("Scrypt.BlockSize", "uses more than %v MiB with LogN %v", maxScryptMemory>>20, scrypt.logN)
+	}
+	if scrypt.r*scrypt.p > maxScryptRP {
+		errs.Add("Scrypt.Parallelism", "times BlockSize must be at most %v", maxScryptRP)
+	}
+
+	pbkdf2 := newPBKDF2Hasher(conf).(pbkdf2Hasher)
+	if pbkdf2.iterations > maxPBKDF2Iterations {
+		errs.Add("PBKDF2.Iterations", "must be at most %v, not %v", maxPBKDF2Iterations, pbkdf2.iterations)
+	}
+}
+
+// validateFiles Checks that the pepper and breach files can be opened, that
+// the data key is valid and kept out of the data directory, and that the
+// data directory is a directory. A missing data directory or data key is
+// created when the task store is opened.
+func validateFiles(errs *config.ValidationErrors, conf config.Config) {
+	if conf.PepperFile != "" {
+		_, err := loadKeyRing(conf)
+		if err != nil {
+			errs.Add("PepperFile", "%v", err)
+		}
+	}
+
+	if conf.BreachFile != "" {
+		blocklist, err := strength.OpenBlocklist(conf.BreachFile)
+		if err != nil {
+			errs.Add("BreachFile", "cannot be opened: %v", err)
+		} else {
+			blocklist.Close()
+		}
+	}
+
+	if conf.DataKeyFile != "" {
+		_, err := readStoreKey(conf.DataKeyFile)
+		if err != nil && !os.IsNotExist(err) {
+			errs.Add("DataKeyFile", "%v", err)
+		}
+		if conf.DataDirectory != "" && inDirectory(conf.DataKeyFile, conf.DataDirectory) {
+			errs.Add("DataKeyFile", "must not be in DataDirectory")
+		}
+	}
+
+	if conf.DataDirectory != "" {
+		info, err := os.Stat(conf.DataDirectory)
+		if err != nil && !os.IsNotExist(err) {
+			errs.Add("DataDirectory", "cannot be read: %v", err)
+		} else if err == nil && !info.IsDir() {
+			errs.Add("DataDirectory", "must be a directory: %v", conf.DataDirectory)
+		}
+	}
+}
+
+// inDirectory Returns true if the file path is in the directory or
+// one of its subdirectories.
+func inDirectory(filePath, dir string) bool {
+	absFile, err := filepath.Abs(filePath)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absDir, absFile)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,113 @@
+package task
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrpalma/pwdhash/config"
+	"github.com/jrpalma/pwdhash/logs"
+	"github.com/jrpalma/pwdhash/pepper"
+)
+
+func TestValidateConfig(t *testing.T) {
+	conf := config.Config{}
+	conf.LogLevel = logs.WARN
+	conf.LogDestination = logs.STDERR
+	conf.ServerAddress = ":8080"
+
+	err := ValidateConfig(conf)
+	if err != nil {
+		t.Errorf("ValidateConfig should accept the config: %v", err)
+	}
+
+	conf.LogLevel = "LOUD"
+	conf.HashAlgorithm = "md5"
+	conf.IDScheme = "counter"
+
+	err = ValidateConfig(conf)
+	errs := config.ValidationErrors{}
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("ValidateConfig should report 3 problems, not %v", err)
+	}
+}
+
+func TestValidateConfig_Limits(t *testing.T) {
+	conf := config.Config{}
+	conf.LogLevel = logs.WARN
+	conf.LogDestination = logs.STDERR
+	conf.ServerAddress = ":8080"
+	conf.Argon2.Memory = maxArgon2Memory + 1
+	conf.Scrypt.LogN = maxScryptLogN
+	conf.Scrypt.BlockSize = 16
+	conf.PBKDF2.Iterations = maxPBKDF2Iterations + 1
+
+	err := ValidateConfig(conf)
+	errs := config.ValidationErrors{}
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("ValidateConfig should report 3 problems, not %v", err)
+	}
+}
+
+func TestValidateConfig_Files(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.Config{}
+	conf.LogLevel = logs.WARN
+	conf.LogDestination = logs.STDERR
+	conf.ServerAddress = ":8080"
+	conf.PepperFile = filepath.Join(dir, "pepper.json")
+	conf.BreachFile = filepath.Join(dir, "breached.txt")
+	conf.DataDirectory = filepath.Join(dir, "data")
+
+	_, err := pepper.RotateFile(conf.PepperFile)
+	if err != nil {
+		t.Errorf("RotateFile failed: %v", err)
+		return
+	}
+	ioutil.WriteFile(conf.BreachFile, []byte("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\n"), 0600)
+
+	// A missing data directory is created by the task store
+	err = ValidateConfig(conf)
+	if err != nil {
+		t.Errorf("ValidateConfig should accept the files: %v", err)
+	}
+
+	conf.PepperFile = filepath.Join(dir, "missing.json")
+	conf.BreachFile = filepath.Join(dir, "missing.txt")
+	conf.DataDirectory = filepath.Join(dir, "breached.txt")
+
+	err = ValidateConfig(conf)
+	errs := config.ValidationErrors{}
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("ValidateConfig should report 3 problems, not %v", err)
+	}
+}
+
+func TestValidateConfig_DataKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.Config{}
+	conf.LogLevel = logs.WARN
+	conf.LogDestination = logs.STDERR
+	conf.ServerAddress = ":8080"
+	conf.DataDirectory = filepath.Join(dir, "data")
+	conf.DataKeyFile = filepath.Join(dir, "store.key")
+
+	// A missing key is created when the store is opened
+	err := ValidateConfig(conf)
+	if err != nil {
+		t.Errorf("ValidateConfig should accept the key file: %v", err)
+	}
+
+	ioutil.WriteFile(conf.DataKeyFile, []byte("short"), 0600)
+	err = ValidateConfig(conf)
+	if err == nil {
+		t.Errorf("ValidateConfig should reject an invalid key")
+	}
+
+	conf.DataKeyFile = filepath.Join(conf.DataDirectory, "store.key")
+	err = ValidateConfig(conf)
+	if err == nil {
+		t.Errorf("ValidateConfig should reject a key in the data directory")
+	}
+}